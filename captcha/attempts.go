@@ -0,0 +1,80 @@
+package captcha
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// verifyAttempts checks the code and counts wrong attempts in KEYS[2].
+// The counter expires with the code; reaching ARGV[2] attempts deletes both.
+// Returns {-1, 0} if the code does not exist, {0, 0} on success and
+// {1, remaining} for a wrong code.
+var verifyAttempts = redis.NewScript(`
+local code = redis.call('GET', KEYS[1])
+if not code then return {-1, 0} end
+if code == ARGV[1] then
+	redis.call('DEL', KEYS[1], KEYS[2])
+	return {0, 0}
+end
+local n = redis.call('INCR', KEYS[2])
+local max = tonumber(ARGV[2])
+if n >= max then
+	redis.call('DEL', KEYS[1], KEYS[2])
+	return {1, 0}
+end
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl > 0 then redis.call('PEXPIRE', KEYS[2], ttl) end
+return {1, max - n}
+`)
+
+// AttemptsError is returned by Verify for a wrong code when SetMaxAttempts is
+// used. It wraps ErrInvalidCode, so errors.Is(err, ErrInvalidCode) holds:
+//
+//	var e *captcha.AttemptsError
+//	if errors.As(err, &e) && e.Remaining == 0 {
+//		// The code is invalidated, request a new one
+//	}
+type AttemptsError struct {
+	// Remaining is the number of attempts left before the code is invalidated.
+	Remaining int64
+}
+
+func (e *AttemptsError) Error() string {
+	return fmt.Sprintf("%s, %d attempts remaining", ErrInvalidCode, e.Remaining)
+}
+
+func (e *AttemptsError) Unwrap() error {
+	return ErrInvalidCode
+}
+
+// SetMaxAttempts lets a code tolerate wrong attempts: Verify only invalidates
+// it after v wrong attempts, reporting the remaining ones in an AttemptsError.
+// Default is 0, where any verification consumes the code.
+func SetMaxAttempts(v int64) Option {
+	return func(x *Captcha) {
+		x.MaxAttempts = v
+	}
+}
+
+// AttemptsKey generates the Redis key counting wrong attempts for a captcha name.
+// Format: "{prefix}:{name}:attempts"
+func (x *Captcha) AttemptsKey(name string) string {
+	return x.Key(name) + ":attempts"
+}
+
+func (x *Captcha) verifyAttempts(ctx context.Context, name string, code string) error {
+	r, err := verifyAttempts.Run(ctx, x.RDb,
+		[]string{x.Key(name), x.AttemptsKey(name)}, code, x.MaxAttempts).Int64Slice()
+	if err != nil {
+		return err
+	}
+	switch r[0] {
+	case -1:
+		return ErrNotExists
+	case 0:
+		return nil
+	}
+	return &AttemptsError{Remaining: r[1]}
+}
@@ -28,6 +28,39 @@
 //		c.JSON(200, utils.H{"message": "verified"})
 //	})
 //
+// # Image Captcha
+//
+// For anonymous forms, CreateImage generates a code, stores it like Create and
+// returns a distorted PNG as a data URI; the answer is checked with Verify:
+//
+//	images := captcha.NewImage(captcha.SetSize(160, 50), captcha.SetLength(5))
+//
+//	h.GET("/captcha/image", func(ctx context.Context, c *app.RequestContext) {
+//		id := help.Uuid7()
+//		src, err := cap.CreateImage(ctx, "image:"+id, 2*time.Minute, images)
+//		if err != nil {
+//			c.JSON(500, utils.H{"error": err.Error()})
+//			return
+//		}
+//		c.JSON(200, utils.H{"id": id, "image": src}) // <img [src]="image">
+//	})
+//
+//	// Codes are uppercase
+//	err := cap.Verify(ctx, "image:"+id, strings.ToUpper(answer))
+//
+// # Attempt Limits
+//
+// By default a wrong code consumes the captcha. With SetMaxAttempts, a code
+// tolerates a number of wrong attempts, counted atomically in Redis:
+//
+//	cap := captcha.New(redisClient, captcha.SetMaxAttempts(5))
+//
+//	var e *captcha.AttemptsError
+//	if err := cap.Verify(ctx, "login:"+email, code); errors.As(err, &e) {
+//		c.JSON(400, utils.H{"error": err.Error(), "remaining": e.Remaining})
+//		return
+//	}
+//
 // # Angular Frontend Setup
 //
 //	// Send captcha request
@@ -43,6 +76,7 @@
 // # Security Notes
 //
 //   - Codes are deleted after successful verification (one-time use)
+//   - Create resets the attempts, so limit how often codes can be requested
 //   - Use appropriate TTL (e.g., 5 minutes) to limit attack window
 //   - Consider rate limiting to prevent brute force attacks
 //   - Use Redis key prefix to namespace different captcha types
@@ -69,6 +103,8 @@ type Captcha struct {
 	RDb *redis.Client
 	// Prefix is the key prefix for all captcha keys (default: "captcha").
 	Prefix string
+	// MaxAttempts is the number of wrong attempts a code tolerates (default: 0, one attempt).
+	MaxAttempts int64
 }
 
 // New creates a new Captcha instance with the given Redis client.
@@ -101,10 +137,27 @@ func (x *Captcha) Key(name string) string {
 }
 
 // Create stores a captcha code with the given name and TTL.
-// If a code already exists for this name, it will be overwritten.
+// If a code already exists for this name, it will be overwritten
+// and its wrong attempts are reset.
 // Returns "OK" on success.
 func (x *Captcha) Create(ctx context.Context, name string, code string, ttl time.Duration) string {
-	return x.RDb.Set(ctx, x.Key(name), code, ttl).Val()
+	status, _ := x.set(ctx, name, code, ttl)
+	return status
+}
+
+func (x *Captcha) set(ctx context.Context, name string, code string, ttl time.Duration) (string, error) {
+	if x.MaxAttempts <= 0 {
+		return x.RDb.Set(ctx, x.Key(name), code, ttl).Result()
+	}
+	var status *redis.StatusCmd
+	if _, err := x.RDb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		status = p.Set(ctx, x.Key(name), code, ttl)
+		p.Del(ctx, x.AttemptsKey(name))
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	return status.Val(), nil
 }
 
 // Exists checks if a captcha code exists for the given name.
@@ -116,8 +169,12 @@ func (x *Captcha) Exists(ctx context.Context, name string) bool {
 // Verify checks if the provided code matches the stored captcha.
 // On successful verification, the code is automatically deleted (one-time use).
 // Returns ErrNotExists if code doesn't exist or expired.
-// Returns ErrInvalidCode if code doesn't match.
+// Returns ErrInvalidCode if code doesn't match; with SetMaxAttempts, the code
+// is kept until the attempts are used up and the error is an *AttemptsError.
 func (x *Captcha) Verify(ctx context.Context, name string, code string) error {
+	if x.MaxAttempts > 0 {
+		return x.verifyAttempts(ctx, name, code)
+	}
 	// Use GetDel for atomic get-and-delete operation
 	result, err := x.RDb.GetDel(ctx, x.Key(name)).Result()
 	if err != nil {
@@ -135,5 +192,8 @@ func (x *Captcha) Verify(ctx context.Context, name string, code string) error {
 // Delete removes a captcha code by name.
 // Returns the number of keys deleted (0 or 1).
 func (x *Captcha) Delete(ctx context.Context, name string) int64 {
+	if x.MaxAttempts > 0 {
+		x.RDb.Del(ctx, x.AttemptsKey(name))
+	}
 	return x.RDb.Del(ctx, x.Key(name)).Val()
 }
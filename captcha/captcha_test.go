@@ -14,19 +14,24 @@ import (
 var x *captcha.Captcha
 
 func TestMain(m *testing.M) {
-	url := os.Getenv("DATABASE_REDIS")
-	if url == "" {
-		os.Exit(0)
+	// Redis-backed tests are skipped when DATABASE_REDIS is not set
+	if url := os.Getenv("DATABASE_REDIS"); url != "" {
+		opts, err := redis.ParseURL(url)
+		if err == nil {
+			x = captcha.New(redis.NewClient(opts))
+		}
 	}
-	opts, err := redis.ParseURL(url)
-	if err != nil {
-		os.Exit(0)
-	}
-	x = captcha.New(redis.NewClient(opts))
 	os.Exit(m.Run())
 }
 
+func requireRedis(t *testing.T) {
+	if x == nil {
+		t.Skip("DATABASE_REDIS is not set")
+	}
+}
+
 func TestKey(t *testing.T) {
+	requireRedis(t)
 	assert.Equal(t, "captcha:login:test", x.Key("login:test"))
 
 	// Test custom prefix
@@ -35,6 +40,7 @@ func TestKey(t *testing.T) {
 }
 
 func TestCreate(t *testing.T) {
+	requireRedis(t)
 	ctx := context.TODO()
 	status := x.Create(ctx, "test1", "123456", time.Minute)
 	assert.Equal(t, "OK", status)
@@ -44,6 +50,7 @@ func TestCreate(t *testing.T) {
 }
 
 func TestExists(t *testing.T) {
+	requireRedis(t)
 	ctx := context.TODO()
 
 	// Create and check exists
@@ -56,6 +63,7 @@ func TestExists(t *testing.T) {
 }
 
 func TestVerify_Success(t *testing.T) {
+	requireRedis(t)
 	ctx := context.TODO()
 	x.Create(ctx, "test3", "correct", time.Minute)
 
@@ -68,6 +76,7 @@ func TestVerify_Success(t *testing.T) {
 }
 
 func TestVerify_InvalidCode(t *testing.T) {
+	requireRedis(t)
 	ctx := context.TODO()
 	x.Create(ctx, "test4", "secret", time.Minute)
 
@@ -80,6 +89,7 @@ func TestVerify_InvalidCode(t *testing.T) {
 }
 
 func TestVerify_NotExists(t *testing.T) {
+	requireRedis(t)
 	ctx := context.TODO()
 
 	// Verify non-existent code
@@ -88,6 +98,7 @@ func TestVerify_NotExists(t *testing.T) {
 }
 
 func TestVerify_Expired(t *testing.T) {
+	requireRedis(t)
 	ctx := context.TODO()
 	x.Create(ctx, "test5", "temp", 50*time.Millisecond)
 
@@ -100,6 +111,7 @@ func TestVerify_Expired(t *testing.T) {
 }
 
 func TestDelete(t *testing.T) {
+	requireRedis(t)
 	ctx := context.TODO()
 	x.Create(ctx, "test6", "todelete", time.Minute)
 
@@ -111,3 +123,51 @@ func TestDelete(t *testing.T) {
 	result = x.Delete(ctx, "test6")
 	assert.Equal(t, int64(0), result)
 }
+
+func TestVerify_MaxAttempts(t *testing.T) {
+	requireRedis(t)
+	ctx := context.TODO()
+	x2 := captcha.New(x.RDb, captcha.SetMaxAttempts(3))
+	x2.Create(ctx, "test7", "123456", time.Minute)
+
+	// Wrong attempts keep the code and report the remaining ones
+	err := x2.Verify(ctx, "test7", "000000")
+	assert.ErrorIs(t, err, captcha.ErrInvalidCode)
+	var e *captcha.AttemptsError
+	assert.ErrorAs(t, err, &e)
+	assert.Equal(t, int64(2), e.Remaining)
+	assert.EqualError(t, err, "captcha: invalid code, 2 attempts remaining")
+	assert.True(t, x2.Exists(ctx, "test7"))
+	assert.Greater(t, x.RDb.PTTL(ctx, x2.AttemptsKey("test7")).Val(), time.Duration(0))
+
+	// A correct code removes the code and the counter
+	assert.NoError(t, x2.Verify(ctx, "test7", "123456"))
+	assert.False(t, x2.Exists(ctx, "test7"))
+	assert.Zero(t, x.RDb.Exists(ctx, x2.AttemptsKey("test7")).Val())
+}
+
+func TestVerify_MaxAttemptsExhausted(t *testing.T) {
+	requireRedis(t)
+	ctx := context.TODO()
+	x2 := captcha.New(x.RDb, captcha.SetMaxAttempts(2))
+	x2.Create(ctx, "test8", "123456", time.Minute)
+
+	var e *captcha.AttemptsError
+	assert.ErrorAs(t, x2.Verify(ctx, "test8", "000000"), &e)
+	assert.Equal(t, int64(1), e.Remaining)
+	assert.ErrorAs(t, x2.Verify(ctx, "test8", "111111"), &e)
+	assert.Equal(t, int64(0), e.Remaining)
+
+	// The code is invalidated, even the correct one is rejected
+	assert.ErrorIs(t, x2.Verify(ctx, "test8", "123456"), captcha.ErrNotExists)
+	assert.Zero(t, x.RDb.Exists(ctx, x2.AttemptsKey("test8")).Val())
+
+	// Create resets the attempts
+	x2.Create(ctx, "test8", "123456", time.Minute)
+	assert.ErrorAs(t, x2.Verify(ctx, "test8", "000000"), &e)
+	x2.Create(ctx, "test8", "654321", time.Minute)
+	assert.ErrorAs(t, x2.Verify(ctx, "test8", "000000"), &e)
+	assert.Equal(t, int64(1), e.Remaining)
+	assert.Equal(t, int64(1), x2.Delete(ctx, "test8"))
+	assert.Zero(t, x.RDb.Exists(ctx, x2.AttemptsKey("test8")).Val())
+}
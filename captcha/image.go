@@ -0,0 +1,237 @@
+package captcha
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/kainonly/go/help"
+)
+
+// Default image captcha configuration values.
+const (
+	DefaultWidth      = 120
+	DefaultHeight     = 40
+	DefaultLength     = 4
+	DefaultNoiseLines = 4
+	// DefaultCharset omits characters that are easily confused (0/O, 1/I).
+	DefaultCharset = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+)
+
+// ErrInvalidImage is returned by Image.Validate for illegal options.
+var ErrInvalidImage = errors.New("captcha: invalid image configuration")
+
+// Image renders distorted text images for visual challenges.
+// Codes are uppercase, so compare user input case-insensitively or upper-case it first.
+type Image struct {
+	// Width is the image width in pixels (default: 120).
+	Width int
+	// Height is the image height in pixels (default: 40).
+	Height int
+	// Length is the number of characters in a code (default: 4).
+	Length int
+	// Charset is the set of characters codes are drawn from, digits and
+	// uppercase letters only (default: DefaultCharset).
+	Charset string
+	// NoiseLines is the number of lines drawn across the text (default: 4).
+	NoiseLines int
+}
+
+// NewImage creates a new Image generator with the given options.
+// It panics if the configuration is invalid, see Validate.
+func NewImage(options ...ImageOption) *Image {
+	x := &Image{
+		Width:      DefaultWidth,
+		Height:     DefaultHeight,
+		Length:     DefaultLength,
+		Charset:    DefaultCharset,
+		NoiseLines: DefaultNoiseLines,
+	}
+	for _, opt := range options {
+		opt(x)
+	}
+	if err := x.Validate(); err != nil {
+		panic(err)
+	}
+	return x
+}
+
+// ImageOption is a function that configures an Image instance.
+type ImageOption func(x *Image)
+
+// SetSize sets the image size in pixels.
+func SetSize(width int, height int) ImageOption {
+	return func(x *Image) {
+		x.Width, x.Height = width, height
+	}
+}
+
+// SetLength sets the number of characters in a code.
+func SetLength(v int) ImageOption {
+	return func(x *Image) {
+		x.Length = v
+	}
+}
+
+// SetCharset sets the characters codes are drawn from, e.g. "0123456789".
+func SetCharset(v string) ImageOption {
+	return func(x *Image) {
+		x.Charset = v
+	}
+}
+
+// SetNoiseLines sets the number of lines drawn across the text.
+func SetNoiseLines(v int) ImageOption {
+	return func(x *Image) {
+		x.NoiseLines = v
+	}
+}
+
+// Validate checks the options. Returns an error wrapping ErrInvalidImage.
+func (x *Image) Validate() error {
+	if x.Width <= 0 || x.Height <= 0 {
+		return fmt.Errorf("%w: size must be positive", ErrInvalidImage)
+	}
+	if x.Length <= 0 {
+		return fmt.Errorf("%w: length must be positive", ErrInvalidImage)
+	}
+	if x.NoiseLines < 0 {
+		return fmt.Errorf("%w: noise lines cannot be negative", ErrInvalidImage)
+	}
+	if x.Charset == "" {
+		return fmt.Errorf("%w: charset cannot be empty", ErrInvalidImage)
+	}
+	for i := 0; i < len(x.Charset); i++ {
+		if _, ok := glyphs[x.Charset[i]]; !ok {
+			return fmt.Errorf("%w: unsupported character %q", ErrInvalidImage, x.Charset[i])
+		}
+	}
+	return nil
+}
+
+// Generate returns a random code and its image.
+func (x *Image) Generate() (string, image.Image) {
+	code := help.Random(x.Length, x.Charset)
+	return code, x.Draw(code)
+}
+
+// Draw renders the code with per-character rotation and jitter, a wave
+// distortion, noise dots and noise lines. Unsupported characters are left blank.
+func (x *Image) Draw(code string) image.Image {
+	bg := color.RGBA{R: uint8(225 + rand.IntN(30)), G: uint8(225 + rand.IntN(30)), B: uint8(225 + rand.IntN(30)), A: 255}
+	src := image.NewRGBA(image.Rect(0, 0, x.Width, x.Height))
+	draw.Draw(src, src.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+
+	w, h := float64(x.Width), float64(x.Height)
+	cell := w / float64(len(code))
+	scale := math.Min(h*0.65/7, cell*0.8/5)
+	for i := 0; i < len(code); i++ {
+		glyph, ok := glyphs[code[i]]
+		if !ok {
+			continue
+		}
+		fg := darkColor()
+		cx := cell*(float64(i)+0.5) + (rand.Float64()-0.5)*cell*0.2
+		cy := h/2 + (rand.Float64()-0.5)*h*0.2
+		angle := (rand.Float64() - 0.5) * 0.6
+		sin, cos := math.Sincos(angle)
+		r := int(scale*4.5) + 1
+		for py := int(cy) - r; py <= int(cy)+r; py++ {
+			for px := int(cx) - r; px <= int(cx)+r; px++ {
+				// Map the pixel back into glyph space
+				dx, dy := float64(px)-cx, float64(py)-cy
+				u := (cos*dx+sin*dy)/scale + 2.5
+				v := (-sin*dx+cos*dy)/scale + 3.5
+				if on(glyph, int(math.Floor(u)), int(math.Floor(v))) {
+					src.SetRGBA(px, py, fg)
+				}
+			}
+		}
+	}
+
+	// Wave distortion in both directions
+	dst := image.NewRGBA(src.Bounds())
+	ampY, freqY, phaseY := h/12, 2*math.Pi/(w*(0.5+rand.Float64()*0.5)), rand.Float64()*2*math.Pi
+	ampX, freqX, phaseX := w/60, 2*math.Pi/(h*(0.8+rand.Float64()*0.4)), rand.Float64()*2*math.Pi
+	for row := 0; row < x.Height; row++ {
+		for col := 0; col < x.Width; col++ {
+			sx := col + int(ampX*math.Sin(float64(row)*freqX+phaseX))
+			sy := row + int(ampY*math.Sin(float64(col)*freqY+phaseY))
+			if image.Pt(sx, sy).In(src.Bounds()) {
+				dst.SetRGBA(col, row, src.RGBAAt(sx, sy))
+			} else {
+				dst.SetRGBA(col, row, bg)
+			}
+		}
+	}
+
+	for i := 0; i < x.Width*x.Height/25; i++ {
+		dst.SetRGBA(rand.IntN(x.Width), rand.IntN(x.Height), randomColor())
+	}
+	for i := 0; i < x.NoiseLines; i++ {
+		line(dst, rand.IntN(x.Width/4+1), rand.IntN(x.Height),
+			x.Width-1-rand.IntN(x.Width/4+1), rand.IntN(x.Height), darkColor())
+	}
+	return dst
+}
+
+// DataURI encodes the image as a "data:image/png;base64,..." URI,
+// ready to be used as the src of an <img> element.
+func DataURI(img image.Image) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// CreateImage generates a code with the generator, stores it like Create and
+// returns the image as a PNG data URI. Check the answer with Verify.
+func (x *Captcha) CreateImage(ctx context.Context, name string, ttl time.Duration, generator *Image) (string, error) {
+	code, img := generator.Generate()
+	uri, err := DataURI(img)
+	if err != nil {
+		return "", err
+	}
+	if _, err = x.set(ctx, name, code, ttl); err != nil {
+		return "", err
+	}
+	return uri, nil
+}
+
+func darkColor() color.RGBA {
+	return color.RGBA{R: uint8(rand.IntN(120)), G: uint8(rand.IntN(120)), B: uint8(rand.IntN(120)), A: 255}
+}
+
+func randomColor() color.RGBA {
+	return color.RGBA{R: uint8(rand.IntN(256)), G: uint8(rand.IntN(256)), B: uint8(rand.IntN(256)), A: 255}
+}
+
+// line draws a straight line from (x0, y0) to (x1, y1).
+func line(img *image.RGBA, x0 int, y0 int, x1 int, y1 int, c color.RGBA) {
+	steps := max(abs(x1-x0), abs(y1-y0))
+	if steps == 0 {
+		img.SetRGBA(x0, y0, c)
+		return
+	}
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		img.SetRGBA(x0+int(math.Round(t*float64(x1-x0))), y0+int(math.Round(t*float64(y1-y0))), c)
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
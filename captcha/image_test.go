@@ -0,0 +1,71 @@
+package captcha_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kainonly/go/captcha"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewImage(t *testing.T) {
+	g := captcha.NewImage()
+	assert.Equal(t, captcha.DefaultWidth, g.Width)
+	assert.Equal(t, captcha.DefaultHeight, g.Height)
+	assert.Equal(t, captcha.DefaultLength, g.Length)
+	assert.Equal(t, captcha.DefaultCharset, g.Charset)
+
+	invalid := []captcha.ImageOption{
+		captcha.SetSize(0, 40),
+		captcha.SetLength(0),
+		captcha.SetCharset(""),
+		captcha.SetCharset("abc"),
+		captcha.SetNoiseLines(-1),
+	}
+	for _, opt := range invalid {
+		assert.Panics(t, func() { captcha.NewImage(opt) })
+	}
+}
+
+func TestImage_Generate(t *testing.T) {
+	g := captcha.NewImage(captcha.SetSize(200, 60), captcha.SetLength(6), captcha.SetCharset("0123456789"))
+	code, img := g.Generate()
+	assert.Len(t, code, 6)
+	assert.Empty(t, strings.Trim(code, "0123456789"))
+	assert.Equal(t, 200, img.Bounds().Dx())
+	assert.Equal(t, 60, img.Bounds().Dy())
+
+	// Different codes render differently
+	assert.NotEqual(t, g.Draw("1111"), g.Draw("7777"))
+}
+
+func TestDataURI(t *testing.T) {
+	_, img := captcha.NewImage().Generate()
+	uri, err := captcha.DataURI(img)
+	assert.NoError(t, err)
+	data, ok := strings.CutPrefix(uri, "data:image/png;base64,")
+	assert.True(t, ok)
+	b, err := base64.StdEncoding.DecodeString(data)
+	assert.NoError(t, err)
+	decoded, err := png.Decode(bytes.NewReader(b))
+	assert.NoError(t, err)
+	assert.Equal(t, img.Bounds(), decoded.Bounds())
+}
+
+func TestCreateImage(t *testing.T) {
+	requireRedis(t)
+	ctx := context.TODO()
+	uri, err := x.CreateImage(ctx, "image1", time.Minute, captcha.NewImage())
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(uri, "data:image/png;base64,"))
+	assert.True(t, x.Exists(ctx, "image1"))
+
+	code := x.RDb.Get(ctx, x.Key("image1")).Val()
+	assert.Len(t, code, captcha.DefaultLength)
+	assert.NoError(t, x.Verify(ctx, "image1", code))
+}
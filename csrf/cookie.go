@@ -0,0 +1,115 @@
+package csrf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol"
+)
+
+// Cookie name prefixes enforced by browsers.
+const (
+	// HostPrefix requires Secure, Path "/" and no Domain; the cookie is locked to the host.
+	HostPrefix = "__Host-"
+	// SecurePrefix requires Secure.
+	SecurePrefix = "__Secure-"
+)
+
+// ErrInvalidConfig is returned by Validate for illegal option combinations.
+var ErrInvalidConfig = errors.New("csrf: invalid configuration")
+
+// SetSameSite sets the SameSite attribute of both cookies.
+// SameSite=None requires Secure.
+func SetSameSite(v protocol.CookieSameSite) Option {
+	return func(x *Csrf) {
+		x.SameSite = v
+	}
+}
+
+// SetPath sets the Path attribute of both cookies.
+func SetPath(v string) Option {
+	return func(x *Csrf) {
+		x.Path = v
+	}
+}
+
+// SetCookieMaxAge sets the cookie lifetime. Zero means session cookies.
+func SetCookieMaxAge(v time.Duration) Option {
+	return func(x *Csrf) {
+		x.CookieMaxAge = v
+	}
+}
+
+// SetSecure sets the Secure attribute of both cookies.
+// Disable it only for local development over plain HTTP.
+func SetSecure(v bool) Option {
+	return func(x *Csrf) {
+		x.Secure = v
+	}
+}
+
+// SetCookiePrefix prepends HostPrefix or SecurePrefix to both cookie names.
+// The frontend must read the prefixed name, e.g. "__Host-XSRF-TOKEN".
+func SetCookiePrefix(v string) Option {
+	return func(x *Csrf) {
+		x.CookiePrefix = v
+	}
+}
+
+// Validate checks that the options are a legal combination.
+// Returns an error wrapping ErrInvalidConfig, and also ErrEmptyKey for empty
+// keys when HMAC tokens are used (no SkipToken and no Store).
+func (x *Csrf) Validate() error {
+	if x.SkipToken && len(x.AllowedOrigins) == 0 && len(x.FetchSites) == 0 && len(x.FetchModes) == 0 {
+		return fmt.Errorf("%w: no check enabled, set allowed origins or fetch metadata without the token check", ErrInvalidConfig)
+	}
+	// Keys are only used for HMAC tokens
+	if !x.SkipToken && x.Store == nil {
+		for _, key := range x.keys() {
+			if key == "" {
+				return fmt.Errorf("%w: %w", ErrInvalidConfig, ErrEmptyKey)
+			}
+		}
+	}
+	if !strings.HasPrefix(x.Path, "/") {
+		return fmt.Errorf(`%w: path must start with "/"`, ErrInvalidConfig)
+	}
+	if x.CookieMaxAge < 0 {
+		return fmt.Errorf("%w: cookie max age cannot be negative", ErrInvalidConfig)
+	}
+	if x.SameSite == protocol.CookieSameSiteNoneMode && !x.Secure {
+		return fmt.Errorf("%w: SameSite=None requires Secure", ErrInvalidConfig)
+	}
+	if x.Store != nil && x.Session == nil {
+		return fmt.Errorf("%w: synchronizer tokens require SetSession", ErrInvalidConfig)
+	}
+	switch x.CookiePrefix {
+	case "":
+	case SecurePrefix:
+		if !x.Secure {
+			return fmt.Errorf("%w: %s prefix requires Secure", ErrInvalidConfig, SecurePrefix)
+		}
+	case HostPrefix:
+		if !x.Secure || x.Path != "/" || x.Domain != "" {
+			return fmt.Errorf(`%w: %s prefix requires Secure, path "/" and no domain`, ErrInvalidConfig, HostPrefix)
+		}
+	default:
+		return fmt.Errorf("%w: unknown cookie prefix %q", ErrInvalidConfig, x.CookiePrefix)
+	}
+	return nil
+}
+
+func (x *Csrf) tokenCookie() string {
+	return x.CookiePrefix + x.CookieName
+}
+
+func (x *Csrf) saltCookie() string {
+	return x.CookiePrefix + x.SaltName
+}
+
+func (x *Csrf) setCookie(c *app.RequestContext, name string, value string, httpOnly bool) {
+	c.SetCookie(name, value, int(x.CookieMaxAge.Seconds()), x.Path, x.Domain, x.SameSite, x.Secure, httpOnly)
+}
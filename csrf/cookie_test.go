@@ -0,0 +1,95 @@
+package csrf_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/kainonly/go/csrf"
+	"github.com/stretchr/testify/assert"
+)
+
+// tokenCookies calls SetToken and returns the Set-Cookie headers by cookie name.
+func tokenCookies(x *csrf.Csrf) map[string]string {
+	return setCookies(request(newRouter(x), "GET", "/csrf"))
+}
+
+func TestSetToken_DefaultCookies(t *testing.T) {
+	cookies := tokenCookies(csrf.New(csrf.SetKey("secret")))
+	salt := cookies[csrf.DefaultSaltName]
+	assert.Contains(t, salt, "path=/")
+	assert.Contains(t, salt, "HttpOnly")
+	assert.Contains(t, salt, "secure")
+	assert.Contains(t, salt, "SameSite=Strict")
+	assert.NotContains(t, salt, "max-age")
+	assert.NotContains(t, cookies[csrf.DefaultCookieName], "HttpOnly")
+}
+
+func TestSetToken_CookieOptions(t *testing.T) {
+	x := csrf.New(
+		csrf.SetKey("secret"),
+		csrf.SetSameSite(protocol.CookieSameSiteLaxMode),
+		csrf.SetPath("/app"),
+		csrf.SetCookieMaxAge(time.Hour),
+		csrf.SetSecure(false),
+		csrf.SetDomain("example.com"),
+	)
+	token := tokenCookies(x)[csrf.DefaultCookieName]
+	assert.Contains(t, token, "path=/app")
+	assert.Contains(t, token, "max-age=3600")
+	assert.Contains(t, token, "SameSite=Lax")
+	assert.Contains(t, token, "domain=example.com")
+	assert.NotContains(t, token, "secure")
+}
+
+func TestSetToken_HostPrefix(t *testing.T) {
+	x := csrf.New(csrf.SetKey("secret"), csrf.SetCookiePrefix(csrf.HostPrefix))
+	cookies := tokenCookies(x)
+	assert.Contains(t, cookies, "__Host-XSRF-TOKEN")
+	assert.Contains(t, cookies, "__Host-XSRF-SALT")
+
+	// The prefixed salt cookie is read back on verification
+	router := newRouter(x)
+	salt := "abcd1234abcd1234"
+	token := ut.Header{Key: csrf.DefaultHeaderName, Value: x.Tokenize(salt)}
+	assert.Equal(t, http.StatusOK, post(router, "/api", ut.Header{Key: "Cookie", Value: "__Host-XSRF-SALT=" + salt}, token))
+	assert.Equal(t, http.StatusForbidden, post(router, "/api", ut.Header{Key: "Cookie", Value: "XSRF-SALT=" + salt}, token))
+}
+
+func TestValidate(t *testing.T) {
+	valid := [][]csrf.Option{
+		{},
+		{csrf.SetCookiePrefix(csrf.HostPrefix)},
+		{csrf.SetCookiePrefix(csrf.SecurePrefix), csrf.SetDomain("example.com"), csrf.SetPath("/app")},
+		{csrf.SetSameSite(protocol.CookieSameSiteNoneMode)},
+		{csrf.SetSecure(false), csrf.SetSameSite(protocol.CookieSameSiteLaxMode)},
+	}
+	for _, options := range valid {
+		assert.NotPanics(t, func() {
+			csrf.New(append([]csrf.Option{csrf.SetKey("secret")}, options...)...)
+		})
+	}
+
+	invalid := [][]csrf.Option{
+		{csrf.SetCookiePrefix(csrf.HostPrefix), csrf.SetDomain("example.com")},
+		{csrf.SetCookiePrefix(csrf.HostPrefix), csrf.SetPath("/app")},
+		{csrf.SetCookiePrefix(csrf.HostPrefix), csrf.SetSecure(false)},
+		{csrf.SetCookiePrefix(csrf.SecurePrefix), csrf.SetSecure(false)},
+		{csrf.SetCookiePrefix("__Other-")},
+		{csrf.SetSameSite(protocol.CookieSameSiteNoneMode), csrf.SetSecure(false)},
+		{csrf.SetPath("app")},
+		{csrf.SetCookieMaxAge(-time.Second)},
+	}
+	for _, options := range invalid {
+		x := &csrf.Csrf{Path: "/", Secure: true, SameSite: protocol.CookieSameSiteStrictMode}
+		for _, opt := range options {
+			opt(x)
+		}
+		assert.ErrorIs(t, x.Validate(), csrf.ErrInvalidConfig)
+		assert.Panics(t, func() {
+			csrf.New(append([]csrf.Option{csrf.SetKey("secret")}, options...)...)
+		})
+	}
+}
@@ -1,7 +1,7 @@
 // Package csrf provides CSRF (Cross-Site Request Forgery) protection middleware for Hertz.
 //
 // It implements the Double Submit Cookie pattern using HMAC-SHA256.
-// Cookies are session-level and automatically cleared when the browser closes.
+// Cookies are session-level by default and automatically cleared when the browser closes.
 //
 // # Hertz Backend Setup
 //
@@ -30,6 +30,121 @@
 //	api.PUT("/update", updateHandler)
 //	api.DELETE("/remove", removeHandler)
 //
+// # Session-Bound Tokens
+//
+// By default a token only depends on the salt cookie. With SetSession the token
+// is also bound to a session identifier (e.g. passport ActiveId or JTI), and with
+// SetTokenMaxAge it carries its issue time and expires:
+//
+//	csrfProtect := csrf.New(
+//		csrf.SetKey("your-secret-key-at-least-32-bytes"),
+//		csrf.SetSession(func(c *app.RequestContext) string {
+//			return passport.GetActiveId(c)
+//		}),
+//		csrf.SetTokenMaxAge(12*time.Hour),
+//	)
+//
+//	// Login - the request is not authenticated yet, bind explicitly
+//	csrfProtect.SetTokenFor(c, userId)
+//
+//	// VerifyToken must run after the authentication middleware
+//	api := h.Group("/api", authn.Authenticate(), csrfProtect.VerifyToken())
+//
+// # Form and Query Tokens
+//
+// Server-rendered forms and file uploads can submit the token in the "_csrf"
+// form field (urlencoded or multipart). A query parameter can be enabled too.
+// The header takes precedence over the form field, and the form field over the query:
+//
+//	csrfProtect := csrf.New(
+//		csrf.SetKey("your-secret-key-at-least-32-bytes"),
+//		csrf.SetFormField("csrf_token"),
+//		csrf.SetQueryParam("csrf_token"),
+//	)
+//
+//	<form method="post" enctype="multipart/form-data">
+//	  <input type="hidden" name="csrf_token" value="{{ .CsrfToken }}">
+//	</form>
+//
+// # Synchronizer Tokens
+//
+// With SetStore, tokens are random values stored in Redis per session and no salt
+// cookie is used. Tokens can be revoked on logout and, with SetSingleUse, are
+// replaced after every verified request (the new token is set as a cookie):
+//
+//	csrfProtect := csrf.New(
+//		csrf.SetKey("your-secret-key-at-least-32-bytes"),
+//		csrf.SetSession(func(c *app.RequestContext) string {
+//			return session.GetActiveId(c)
+//		}),
+//		csrf.SetStore(csrf.NewStore(redisClient, csrf.SetSingleUse(true))),
+//	)
+//
+//	// Login - handle Redis errors explicitly
+//	if err := csrfProtect.Issue(ctx, c, userId); err != nil {
+//		c.Error(err)
+//		return
+//	}
+//
+//	// Logout
+//	csrfProtect.Store.Revoke(ctx, userId)
+//
+// # Origin and Fetch Metadata Checks
+//
+// As defence in depth, the Origin (or Referer) header can be checked against an
+// allow-list and the Sec-Fetch-Site/Sec-Fetch-Mode headers against accepted values.
+// Each failure has its own error (ErrInvalidOrigin, ErrInvalidFetchSite, ...).
+// The checks run before the token check, or instead of it with SetCheckToken(false):
+//
+//	csrfProtect := csrf.New(
+//		csrf.SetKey("your-secret-key-at-least-32-bytes"),
+//		csrf.SetAllowedOrigins("https://app.example.com"),
+//		csrf.SetFetchSites("same-origin", "same-site", "none"),
+//	)
+//
+// # Error Handling
+//
+// Failures abort with 403 and {"code": 0, "message": "..."} by default. Codes can
+// be set per error, and PublicErrorHandler hands failures to help.ErrorHandler:
+//
+//	csrfProtect := csrf.New(
+//		csrf.SetKey("your-secret-key-at-least-32-bytes"),
+//		csrf.SetErrorCodes(map[error]int64{csrf.ErrInvalidToken: 4003}),
+//		csrf.SetErrorHandler(csrf.PublicErrorHandler),
+//	)
+//
+// # Exemptions
+//
+// Webhooks and OAuth callbacks in a protected group can bypass verification
+// by path pattern, a custom skipper or, for pure bearer-token APIs, the
+// presence of an Authorization header:
+//
+//	csrfProtect := csrf.New(
+//		csrf.SetKey("your-secret-key-at-least-32-bytes"),
+//		csrf.SetExemptPaths("/webhooks/**", "/oauth/*/callback"),
+//		csrf.SetSkipper(func(c *app.RequestContext) bool { return isInternal(c) }),
+//		csrf.SetExemptBearer(true),
+//	)
+//
+// # Key Rotation
+//
+// SetKeys takes the keys newest first: new tokens are signed with the first key,
+// tokens signed with any of them are accepted. New panics with ErrEmptyKey if a
+// key is empty; no key is needed with SetCheckToken(false) or SetStore:
+//
+//	csrf.New(csrf.SetKeys(os.Getenv("CSRF_KEY"), os.Getenv("CSRF_KEY_PREVIOUS")))
+//
+// # Cookie Attributes
+//
+// Both cookies default to SameSite=Strict, Path "/", Secure and session lifetime.
+// These can be changed; New panics on illegal combinations (see Validate):
+//
+//	// Local development over plain HTTP
+//	csrf.New(csrf.SetKey(key), csrf.SetSecure(false), csrf.SetSameSite(protocol.CookieSameSiteLaxMode))
+//
+//	// Host-locked cookies named "__Host-XSRF-TOKEN" and "__Host-XSRF-SALT"
+//	csrf.New(csrf.SetKey(key), csrf.SetCookiePrefix(csrf.HostPrefix))
+//
 // # Angular Frontend Setup
 //
 // Angular has built-in XSRF support that works with default cookie/header names.
@@ -87,11 +202,13 @@
 //
 // # Security Notes
 //
-//   - Cookies are session-level (cleared when browser closes)
+//   - Cookies are session-level (cleared when browser closes) unless SetCookieMaxAge is used
+//   - Prefer SetCookiePrefix(csrf.HostPrefix) so subdomains cannot overwrite the cookies
 //   - XSRF-TOKEN cookie is readable by JavaScript (HttpOnly=false)
 //   - XSRF-SALT cookie is HttpOnly=true for additional security
 //   - Both cookies use SameSite=Strict to prevent cross-site requests
 //   - Refresh CSRF token after login to prevent pre-auth token theft
+//   - Bind tokens to the session with SetSession so they cannot be transplanted
 //   - Always use HTTPS in production
 package csrf
 
@@ -101,10 +218,13 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cloudwego/hertz/pkg/app"
-	"github.com/cloudwego/hertz/pkg/common/utils"
 	"github.com/cloudwego/hertz/pkg/protocol"
 	"github.com/kainonly/go/help"
 )
@@ -114,6 +234,7 @@ const (
 	DefaultCookieName = "XSRF-TOKEN"
 	DefaultSaltName   = "XSRF-SALT"
 	DefaultHeaderName = "X-XSRF-TOKEN"
+	DefaultFormField  = "_csrf"
 	DefaultSaltLength = 16
 )
 
@@ -122,6 +243,7 @@ var (
 	ErrMissingHeader = errors.New("csrf: missing token in header")
 	ErrMissingSalt   = errors.New("csrf: missing salt cookie")
 	ErrInvalidToken  = errors.New("csrf: invalid token")
+	ErrTokenExpired  = errors.New("csrf: token expired")
 	ErrEmptyKey      = errors.New("csrf: secret key cannot be empty")
 )
 
@@ -133,16 +255,64 @@ type Csrf struct {
 	HeaderName    string
 	Domain        string
 	IgnoreMethods map[string]bool
+	// RotatedKeys are retired keys whose tokens are still accepted, newest first (default: nil).
+	RotatedKeys []string
+	// FormField is the urlencoded or multipart form field carrying the token
+	// when the header is absent (default: "_csrf", "" disables).
+	FormField string
+	// QueryParam is the query parameter carrying the token when neither the
+	// header nor the form field is present (default: "", disabled).
+	QueryParam string
+	// Session returns the session identifier tokens are bound to (default: nil, unbound).
+	Session func(c *app.RequestContext) string
+	// TokenMaxAge is how long a token is valid after it was issued (default: 0, no limit).
+	TokenMaxAge time.Duration
+	// SkipToken disables the double-submit token check (default: false).
+	SkipToken bool
+	// AllowedOrigins is the Origin/Referer allow-list (default: nil, not checked).
+	AllowedOrigins []string
+	// FetchSites are the accepted Sec-Fetch-Site values (default: nil, not checked).
+	FetchSites []string
+	// FetchModes are the accepted Sec-Fetch-Mode values (default: nil, not checked).
+	FetchModes []string
+	// SameSite is the SameSite attribute of both cookies (default: Strict).
+	SameSite protocol.CookieSameSite
+	// Path is the Path attribute of both cookies (default: "/").
+	Path string
+	// CookieMaxAge is the cookie lifetime (default: 0, session cookies).
+	CookieMaxAge time.Duration
+	// Secure is the Secure attribute of both cookies (default: true).
+	Secure bool
+	// CookiePrefix is prepended to both cookie names: "", "__Host-" or "__Secure-" (default: "").
+	CookiePrefix string
+	// ExemptPaths are path patterns that skip verification (default: nil).
+	ExemptPaths []string
+	// Skipper skips verification when it returns true (default: nil).
+	Skipper func(c *app.RequestContext) bool
+	// ExemptBearer skips verification for requests with a bearer token (default: false).
+	ExemptBearer bool
+	// ErrorHandler responds to failed verifications (default: DefaultErrorHandler, also used if nil).
+	ErrorHandler ErrorHandler
+	// Store keeps synchronizer tokens in Redis instead of the salt cookie (default: nil).
+	Store *Store
+	// Codes maps errors to the numeric codes passed to ErrorHandler (default: nil, all 0).
+	Codes map[error]int64
 }
 
 // New creates a new Csrf instance with the given options.
-// At minimum, SetKey must be provided with a secret key.
+// At minimum, SetKey (or SetKeys) must be provided with a secret key.
+// It panics if the configuration is invalid, see Validate.
 func New(options ...Option) *Csrf {
 	x := &Csrf{
-		CookieName: DefaultCookieName,
-		SaltName:   DefaultSaltName,
-		HeaderName: DefaultHeaderName,
-		Domain:     "",
+		CookieName:   DefaultCookieName,
+		SaltName:     DefaultSaltName,
+		HeaderName:   DefaultHeaderName,
+		FormField:    DefaultFormField,
+		Domain:       "",
+		SameSite:     protocol.CookieSameSiteStrictMode,
+		Path:         "/",
+		Secure:       true,
+		ErrorHandler: DefaultErrorHandler,
 		IgnoreMethods: map[string]bool{
 			"GET":     true,
 			"HEAD":    true,
@@ -153,6 +323,9 @@ func New(options ...Option) *Csrf {
 	for _, v := range options {
 		v(x)
 	}
+	if err := x.Validate(); err != nil {
+		panic(err)
+	}
 	return x
 }
 
@@ -205,57 +378,201 @@ func SetDomain(v string) Option {
 	}
 }
 
+// SetSession binds tokens to a session identifier read from the request,
+// such as the passport JTI or ActiveId. A token issued for one session is
+// rejected for any other, so tokens cannot be transplanted between users.
+//
+//	csrf.SetSession(func(c *app.RequestContext) string {
+//		return passport.GetActiveId(c)
+//	})
+func SetSession(v func(c *app.RequestContext) string) Option {
+	return func(x *Csrf) {
+		x.Session = v
+	}
+}
+
+// SetTokenMaxAge sets how long a token is valid after it was issued.
+// Tokens carry their issue time; expired tokens fail with ErrTokenExpired.
+func SetTokenMaxAge(v time.Duration) Option {
+	return func(x *Csrf) {
+		x.TokenMaxAge = v
+	}
+}
+
 // SetToken generates and sets CSRF cookies on the response.
-// Cookies are session-level (deleted when browser closes).
+// Cookies are session-level by default (deleted when browser closes).
 // Call this on login or when the frontend needs a fresh token.
+// With SetSession, the token is bound to the session of the request.
 func (x *Csrf) SetToken(c *app.RequestContext) {
+	x.SetTokenFor(c, x.session(c))
+}
+
+// SetTokenFor sets CSRF cookies bound to the given session identifier.
+// Use it on login, when the request is not authenticated yet:
+//
+//	token, _ := auth.Create(claims)
+//	csrfProtect.SetTokenFor(c, claims.ActiveId)
+//
+// With SetStore, errors from Redis are recorded with c.Error; use Issue to handle them.
+func (x *Csrf) SetTokenFor(c *app.RequestContext, session string) {
+	if err := x.Issue(context.Background(), c, session); err != nil {
+		c.Error(err)
+	}
+}
+
+// Issue sets CSRF cookies bound to the given session identifier.
+// With SetStore, the token is generated and stored in Redis instead of being
+// derived from a salt cookie; it returns ErrMissingSession if session is empty.
+func (x *Csrf) Issue(ctx context.Context, c *app.RequestContext, session string) error {
+	if x.Store != nil {
+		token, err := x.Store.Issue(ctx, session)
+		if err != nil {
+			return err
+		}
+		x.setCookie(c, x.tokenCookie(), token, false)
+		return nil
+	}
 	salt := help.Random(DefaultSaltLength)
-	c.SetCookie(x.SaltName, salt, 0, "/", x.Domain, protocol.CookieSameSiteStrictMode, true, true)
-	c.SetCookie(x.CookieName, x.Tokenize(salt), 0, "/", x.Domain, protocol.CookieSameSiteStrictMode, true, false)
+	token := x.Tokenize(salt)
+	if x.bound() {
+		token = x.TokenizeFor(salt, session, time.Now())
+	}
+	x.setCookie(c, x.saltCookie(), salt, true)
+	x.setCookie(c, x.tokenCookie(), token, false)
+	return nil
+}
+
+// bound reports whether tokens carry a session binding and issue time.
+func (x *Csrf) bound() bool {
+	return x.Session != nil || x.TokenMaxAge > 0
+}
+
+func (x *Csrf) session(c *app.RequestContext) string {
+	if x.Session == nil {
+		return ""
+	}
+	return x.Session(c)
 }
 
 // Tokenize creates an HMAC-SHA256 token from the given salt.
 func (x *Csrf) Tokenize(salt string) string {
-	h := hmac.New(sha256.New, []byte(x.Key))
+	return tokenize(x.Key, salt)
+}
+
+func tokenize(key string, salt string) string {
+	h := hmac.New(sha256.New, []byte(key))
 	h.Write([]byte(salt))
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+// TokenizeFor creates a token bound to the salt, a session identifier and the
+// issue time. Format: "{unix seconds}.{hex HMAC-SHA256}".
+func (x *Csrf) TokenizeFor(salt string, session string, issuedAt time.Time) string {
+	return tokenizeFor(x.Key, salt, session, issuedAt)
+}
+
+func tokenizeFor(key string, salt string, session string, issuedAt time.Time) string {
+	ts := strconv.FormatInt(issuedAt.Unix(), 10)
+	h := hmac.New(sha256.New, []byte(key))
+	// Length prefixes keep the fields unambiguous
+	fmt.Fprintf(h, "%d:%s%d:%s%s", len(salt), salt, len(session), session, ts)
+	return ts + "." + hex.EncodeToString(h.Sum(nil))
+}
+
 // VerifyToken returns a Hertz middleware that validates CSRF tokens.
-// Safe methods (GET, HEAD, OPTIONS, TRACE) are skipped by default.
+// Safe methods (GET, HEAD, OPTIONS, TRACE) are skipped by default,
+// as well as requests exempted by ExemptPaths, Skipper or ExemptBearer.
+// Failed verifications are answered by the ErrorHandler; other errors, such as
+// Redis failures of the Store, abort with 500 and are recorded with c.Error.
 func (x *Csrf) VerifyToken() app.HandlerFunc {
 	return func(ctx context.Context, c *app.RequestContext) {
-		if x.IgnoreMethods[string(c.Method())] {
+		if x.Skip(c) {
 			c.Next(ctx)
 			return
 		}
-
-		salt := string(c.Cookie(x.SaltName))
-		if salt == "" {
-			c.AbortWithStatusJSON(http.StatusForbidden, utils.H{
-				"code":    0,
-				"message": ErrMissingSalt.Error(),
-			})
+		if err := x.Verify(ctx, c); err != nil {
+			if !isRequestError(err) {
+				c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+			handler := x.ErrorHandler
+			if handler == nil {
+				handler = DefaultErrorHandler
+			}
+			handler(ctx, c, err, x.Code(err))
 			return
 		}
+		// Single-use tokens are replaced after every use
+		if x.Store != nil && x.Store.SingleUse && !x.SkipToken {
+			if err := x.Issue(ctx, c, x.session(c)); err != nil {
+				c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+		}
+		c.Next(ctx)
+	}
+}
+
+// Verify runs the configured checks in order: Fetch Metadata, Origin/Referer
+// and the double-submit (or synchronizer) token. It returns the error of the first failing check.
+func (x *Csrf) Verify(ctx context.Context, c *app.RequestContext) error {
+	if err := x.VerifyFetchMetadata(c); err != nil {
+		return err
+	}
+	if err := x.VerifyOrigin(c); err != nil {
+		return err
+	}
+	if x.SkipToken {
+		return nil
+	}
+	return x.verifyToken(ctx, c)
+}
 
-		token := c.GetHeader(x.HeaderName)
+// verifyToken validates the double-submit token of the request, or with SetStore
+// the synchronizer token of the session.
+// Returns ErrMissingSalt, ErrMissingHeader, ErrMissingSession, ErrInvalidToken or ErrTokenExpired.
+func (x *Csrf) verifyToken(ctx context.Context, c *app.RequestContext) error {
+	if x.Store != nil {
+		token := x.Token(c)
 		if token == nil {
-			c.AbortWithStatusJSON(http.StatusForbidden, utils.H{
-				"code":    0,
-				"message": ErrMissingHeader.Error(),
-			})
-			return
+			return ErrMissingHeader
 		}
-
-		if !hmac.Equal([]byte(x.Tokenize(salt)), token) {
-			c.AbortWithStatusJSON(http.StatusForbidden, utils.H{
-				"code":    0,
-				"message": ErrInvalidToken.Error(),
-			})
-			return
+		return x.Store.Check(ctx, x.session(c), string(token))
+	}
+	salt := string(c.Cookie(x.saltCookie()))
+	if salt == "" {
+		return ErrMissingSalt
+	}
+	token := x.Token(c)
+	if token == nil {
+		return ErrMissingHeader
+	}
+	if !x.bound() {
+		for _, key := range x.keys() {
+			if hmac.Equal([]byte(tokenize(key, salt)), token) {
+				return nil
+			}
 		}
+		return ErrInvalidToken
+	}
 
-		c.Next(ctx)
+	ts, _, ok := strings.Cut(string(token), ".")
+	if !ok {
+		return ErrInvalidToken
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	issuedAt := time.Unix(unix, 0)
+	if !x.matchFor(salt, x.session(c), issuedAt, token) {
+		return ErrInvalidToken
+	}
+	if x.TokenMaxAge > 0 {
+		// Tokens from the future are only accepted within one minute of clock skew
+		if age := time.Since(issuedAt); age > x.TokenMaxAge || age < -time.Minute {
+			return ErrTokenExpired
+		}
 	}
+	return nil
 }
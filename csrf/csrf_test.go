@@ -5,6 +5,7 @@ import (
 	"context"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/common/config"
@@ -123,3 +124,58 @@ func TestSetToken(t *testing.T) {
 	resp := w.Result()
 	assert.Equal(t, http.StatusOK, resp.StatusCode())
 }
+
+func TestVerifyToken_Session(t *testing.T) {
+	x := csrf.New(csrf.SetKey("secret"), csrf.SetSession(userSession))
+	router := newRouter(x)
+	salt := "abcd1234abcd1234"
+	token := x.TokenizeFor(salt, "alice", time.Now())
+
+	assert.Equal(t, http.StatusOK, postWithToken(router, salt, token, "alice"))
+	// Tokens cannot be transplanted to another session
+	assert.Equal(t, http.StatusForbidden, postWithToken(router, salt, token, "bob"))
+	// Unbound tokens are rejected
+	assert.Equal(t, http.StatusForbidden, postWithToken(router, salt, x.Tokenize(salt), "alice"))
+	// The issue time is covered by the HMAC
+	assert.Equal(t, http.StatusForbidden, postWithToken(router, salt, "9"+token[1:], "alice"))
+}
+
+func TestVerifyToken_MaxAge(t *testing.T) {
+	x := csrf.New(csrf.SetKey("secret"), csrf.SetTokenMaxAge(time.Hour))
+	router := newRouter(x)
+	salt := "abcd1234abcd1234"
+
+	assert.Equal(t, http.StatusOK, postWithToken(router, salt, x.TokenizeFor(salt, "", time.Now()), ""))
+	assert.Equal(t, http.StatusForbidden, postWithToken(router, salt, x.TokenizeFor(salt, "", time.Now().Add(-2*time.Hour)), ""))
+	assert.Equal(t, http.StatusForbidden, postWithToken(router, salt, x.TokenizeFor(salt, "", time.Now().Add(time.Hour)), ""))
+	assert.Equal(t, http.StatusForbidden, postWithToken(router, salt, "invalid", ""))
+}
+
+func TestVerify_Errors(t *testing.T) {
+	x := csrf.New(csrf.SetKey("secret"), csrf.SetTokenMaxAge(time.Minute))
+	salt := "abcd1234abcd1234"
+	cookie := ut.Header{Key: "Cookie", Value: "XSRF-SALT=" + salt}
+	token := func(v string) ut.Header {
+		return ut.Header{Key: csrf.DefaultHeaderName, Value: v}
+	}
+
+	assert.ErrorIs(t, verifyWith(x, cookie, token(x.TokenizeFor(salt, "", time.Now().Add(-time.Hour)))), csrf.ErrTokenExpired)
+	assert.NoError(t, verifyWith(x, cookie, token(x.TokenizeFor(salt, "", time.Now()))))
+	assert.ErrorIs(t, verifyWith(x, cookie, token(x.Tokenize(salt))), csrf.ErrInvalidToken)
+}
+
+func TestSetTokenFor(t *testing.T) {
+	x := csrf.New(csrf.SetKey("secret"), csrf.SetSession(userSession), csrf.SetTokenMaxAge(time.Hour))
+	router := newRouter(x)
+	router.POST("/login", func(ctx context.Context, c *app.RequestContext) {
+		x.SetTokenFor(c, "alice")
+	})
+
+	w := request(router, "POST", "/login")
+	salt, token := cookieValue(w, csrf.DefaultSaltName), cookieValue(w, csrf.DefaultCookieName)
+	assert.NotEmpty(t, salt)
+	assert.Contains(t, token, ".")
+
+	assert.Equal(t, http.StatusOK, postWithToken(router, salt, token, "alice"))
+	assert.Equal(t, http.StatusForbidden, postWithToken(router, salt, token, "bob"))
+}
@@ -0,0 +1,92 @@
+package csrf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/kainonly/go/help"
+)
+
+// requestErrors are the verification failures of a request, answered by the
+// ErrorHandler. Other errors, such as Redis failures of the Store, are server errors.
+var requestErrors = []error{
+	ErrMissingHeader,
+	ErrMissingSalt,
+	ErrInvalidToken,
+	ErrTokenExpired,
+	ErrMissingSession,
+	ErrMissingOrigin,
+	ErrInvalidOrigin,
+	ErrInvalidReferer,
+	ErrInvalidFetchSite,
+	ErrInvalidFetchMode,
+}
+
+// isRequestError reports whether err is a verification failure of the request.
+func isRequestError(err error) bool {
+	for _, v := range requestErrors {
+		if errors.Is(err, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorHandler responds to a failed verification.
+// code is the numeric code configured for err with SetErrorCodes.
+// The handler must abort the request.
+type ErrorHandler func(ctx context.Context, c *app.RequestContext, err error, code int64)
+
+// DefaultErrorHandler aborts with 403 and a help.R body ({"code": code, "message": "..."}).
+func DefaultErrorHandler(ctx context.Context, c *app.RequestContext, err error, code int64) {
+	c.AbortWithStatusJSON(http.StatusForbidden, help.Fail(code, err.Error()))
+}
+
+// PublicErrorHandler records the failure as a help.E public error and aborts,
+// leaving the response to help.ErrorHandler, so CSRF failures are rendered
+// like every other error of the application:
+//
+//	h.Use(help.ErrorHandler())
+//	csrfProtect := csrf.New(
+//		csrf.SetKey("your-secret-key-at-least-32-bytes"),
+//		csrf.SetErrorHandler(csrf.PublicErrorHandler),
+//		csrf.SetErrorCodes(map[error]int64{
+//			csrf.ErrMissingSalt:   4001,
+//			csrf.ErrMissingHeader: 4002,
+//			csrf.ErrInvalidToken:  4003,
+//		}),
+//	)
+func PublicErrorHandler(ctx context.Context, c *app.RequestContext, err error, code int64) {
+	c.Error(help.E(code, err.Error()))
+	c.Abort()
+}
+
+// SetErrorHandler sets the handler for failed verifications.
+func SetErrorHandler(v ErrorHandler) Option {
+	return func(x *Csrf) {
+		x.ErrorHandler = v
+	}
+}
+
+// SetErrorCodes sets the numeric codes of errors, such as ErrMissingSalt,
+// ErrMissingHeader or ErrInvalidToken. Unlisted errors have code 0.
+func SetErrorCodes(v map[error]int64) Option {
+	return func(x *Csrf) {
+		x.Codes = v
+	}
+}
+
+// Code returns the numeric code configured for the error, or 0.
+func (x *Csrf) Code(err error) int64 {
+	if code, ok := x.Codes[err]; ok {
+		return code
+	}
+	for k, code := range x.Codes {
+		if errors.Is(err, k) {
+			return code
+		}
+	}
+	return 0
+}
@@ -0,0 +1,83 @@
+package csrf_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/kainonly/go/csrf"
+	"github.com/kainonly/go/help"
+	"github.com/stretchr/testify/assert"
+)
+
+var codes = map[error]int64{
+	csrf.ErrMissingSalt:   4001,
+	csrf.ErrMissingHeader: 4002,
+	csrf.ErrInvalidToken:  4003,
+}
+
+func TestCode(t *testing.T) {
+	x := csrf.New(csrf.SetKey("secret"), csrf.SetErrorCodes(codes))
+	assert.Equal(t, int64(4001), x.Code(csrf.ErrMissingSalt))
+	assert.Equal(t, int64(4003), x.Code(fmt.Errorf("wrapped: %w", csrf.ErrInvalidToken)))
+	assert.Equal(t, int64(0), x.Code(csrf.ErrTokenExpired))
+}
+
+func TestDefaultErrorHandler_Codes(t *testing.T) {
+	x := csrf.New(csrf.SetKey("secret"), csrf.SetErrorCodes(codes))
+	router := newRouter(x)
+	salt := "abcd1234abcd1234"
+
+	w := request(router, "POST", "/api")
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode())
+	assert.JSONEq(t, `{"code":4001,"message":"csrf: missing salt cookie"}`, string(w.Result().Body()))
+
+	w = request(router, "POST", "/api", ut.Header{Key: "Cookie", Value: "XSRF-SALT=" + salt})
+	assert.JSONEq(t, `{"code":4002,"message":"csrf: missing token in header"}`, string(w.Result().Body()))
+
+	w = request(router, "POST", "/api",
+		ut.Header{Key: "Cookie", Value: "XSRF-SALT=" + salt},
+		ut.Header{Key: "X-XSRF-TOKEN", Value: "invalid"})
+	assert.JSONEq(t, `{"code":4003,"message":"csrf: invalid token"}`, string(w.Result().Body()))
+}
+
+func TestDefaultErrorHandler_Nil(t *testing.T) {
+	x := &csrf.Csrf{Key: "secret", SaltName: csrf.DefaultSaltName, HeaderName: csrf.DefaultHeaderName}
+	w := request(newRouter(x), "POST", "/api")
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode())
+	assert.JSONEq(t, `{"code":0,"message":"csrf: missing salt cookie"}`, string(w.Result().Body()))
+}
+
+func TestPublicErrorHandler(t *testing.T) {
+	x := csrf.New(
+		csrf.SetKey("secret"),
+		csrf.SetErrorHandler(csrf.PublicErrorHandler),
+		csrf.SetErrorCodes(codes),
+	)
+	router := route.NewEngine(config.NewOptions([]config.Option{}))
+	router.Use(help.ErrorHandler())
+	router.POST("/api", x.VerifyToken(), func(ctx context.Context, c *app.RequestContext) {
+		t.Fatal("handler must not run")
+	})
+
+	w := request(router, "POST", "/api")
+	assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode())
+	assert.JSONEq(t, `{"code":4001,"message":"csrf: missing salt cookie"}`, string(w.Result().Body()))
+}
+
+func TestSetErrorHandler(t *testing.T) {
+	var got error
+	x := csrf.New(csrf.SetKey("secret"), csrf.SetErrorHandler(
+		func(ctx context.Context, c *app.RequestContext, err error, code int64) {
+			got = err
+			c.AbortWithStatus(http.StatusTeapot)
+		}))
+	w := request(newRouter(x), "POST", "/api")
+	assert.Equal(t, http.StatusTeapot, w.Result().StatusCode())
+	assert.ErrorIs(t, got, csrf.ErrMissingSalt)
+}
@@ -0,0 +1,125 @@
+package csrf_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/kainonly/go/csrf"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// newRouter serves GET /csrf, which issues a token with SetToken, and
+// protects POST on the given paths (default: /api) with VerifyToken.
+func newRouter(x *csrf.Csrf, paths ...string) *route.Engine {
+	if len(paths) == 0 {
+		paths = []string{"/api"}
+	}
+	router := route.NewEngine(config.NewOptions([]config.Option{}))
+	router.GET("/csrf", func(ctx context.Context, c *app.RequestContext) {
+		x.SetToken(c)
+	})
+	for _, path := range paths {
+		router.POST(path, x.VerifyToken(), func(ctx context.Context, c *app.RequestContext) {
+			c.JSON(http.StatusOK, utils.H{"ok": 1})
+		})
+	}
+	return router
+}
+
+// request performs a request without a body.
+func request(router *route.Engine, method string, path string, headers ...ut.Header) *ut.ResponseRecorder {
+	return ut.PerformRequest(router, method, path, &ut.Body{Body: bytes.NewBuffer(nil)}, headers...)
+}
+
+// post performs a POST request and returns the status code.
+func post(router *route.Engine, path string, headers ...ut.Header) int {
+	return request(router, "POST", path, headers...).Result().StatusCode()
+}
+
+// postWithToken posts to /api with the salt cookie, the token header and the X-User session.
+func postWithToken(router *route.Engine, salt string, token string, user string) int {
+	return post(router, "/api",
+		ut.Header{Key: "Cookie", Value: csrf.DefaultSaltName + "=" + salt},
+		ut.Header{Key: csrf.DefaultHeaderName, Value: token},
+		ut.Header{Key: "X-User", Value: user})
+}
+
+// userSession reads the session from the X-User header.
+func userSession(c *app.RequestContext) string {
+	return string(c.GetHeader("X-User"))
+}
+
+// setCookies returns the Set-Cookie headers of the response by cookie name.
+func setCookies(w *ut.ResponseRecorder) map[string]string {
+	cookies := map[string]string{}
+	w.Result().Header.VisitAllCookie(func(key, value []byte) {
+		cookies[string(key)] = string(value)
+	})
+	return cookies
+}
+
+// cookieValue returns the value of the named cookie set by the response.
+func cookieValue(w *ut.ResponseRecorder, name string) string {
+	cookie := protocol.AcquireCookie()
+	defer protocol.ReleaseCookie(cookie)
+	_ = cookie.Parse(setCookies(w)[name])
+	return string(cookie.Value())
+}
+
+// issue requests a token from GET /csrf for the user.
+func issue(router *route.Engine, user string) string {
+	w := request(router, "GET", "/csrf", ut.Header{Key: "X-User", Value: user})
+	return cookieValue(w, csrf.DefaultCookieName)
+}
+
+// verifyWith runs Verify on a POST request with the given headers.
+func verifyWith(x *csrf.Csrf, headers ...ut.Header) error {
+	var err error
+	router := route.NewEngine(config.NewOptions([]config.Option{}))
+	router.POST("/api", func(ctx context.Context, c *app.RequestContext) {
+		err = x.Verify(ctx, c)
+	})
+	ut.PerformRequest(router, "POST", "/api", nil, headers...)
+	return err
+}
+
+// tokenOf returns the token Token reads from a POST request.
+func tokenOf(x *csrf.Csrf, path string, body *ut.Body, headers ...ut.Header) string {
+	var token []byte
+	router := route.NewEngine(config.NewOptions([]config.Option{}))
+	router.POST("/api", func(ctx context.Context, c *app.RequestContext) {
+		token = x.Token(c)
+	})
+	ut.PerformRequest(router, "POST", path, body, headers...)
+	return string(token)
+}
+
+// form returns an url-encoded form body and its Content-Type header.
+func form(values url.Values) (*ut.Body, ut.Header) {
+	s := values.Encode()
+	return &ut.Body{Body: strings.NewReader(s), Len: len(s)},
+		ut.Header{Key: "Content-Type", Value: "application/x-www-form-urlencoded"}
+}
+
+// newStore returns a Store on DATABASE_REDIS, skipping the test when it is not set.
+func newStore(t *testing.T, options ...csrf.StoreOption) *csrf.Store {
+	dsn := os.Getenv("DATABASE_REDIS")
+	if dsn == "" {
+		t.Skip("DATABASE_REDIS is not set")
+	}
+	opts, err := redis.ParseURL(dsn)
+	assert.NoError(t, err)
+	return csrf.NewStore(redis.NewClient(opts), append([]csrf.StoreOption{csrf.SetStorePrefix("test:csrf")}, options...)...)
+}
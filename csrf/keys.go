@@ -0,0 +1,37 @@
+package csrf
+
+import (
+	"crypto/hmac"
+	"time"
+)
+
+// SetKeys sets the secret keys, newest first. Tokens are signed with the
+// first key and accepted under any of them, so a key can be rotated without
+// invalidating the tokens of open browser tabs:
+//
+//	csrf.SetKeys(newKey, oldKey)
+//
+// Drop the old key once TokenMaxAge (or CookieMaxAge) has passed.
+func SetKeys(v ...string) Option {
+	return func(x *Csrf) {
+		x.Key, x.RotatedKeys = "", nil
+		if len(v) != 0 {
+			x.Key, x.RotatedKeys = v[0], v[1:]
+		}
+	}
+}
+
+// keys returns the signing key followed by the rotated keys.
+func (x *Csrf) keys() []string {
+	return append([]string{x.Key}, x.RotatedKeys...)
+}
+
+// matchFor reports whether token is a bound token for the arguments under any key.
+func (x *Csrf) matchFor(salt string, session string, issuedAt time.Time, token []byte) bool {
+	for _, key := range x.keys() {
+		if hmac.Equal([]byte(tokenizeFor(key, salt, session, issuedAt)), token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package csrf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/kainonly/go/csrf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetKeys(t *testing.T) {
+	x := csrf.New(csrf.SetKeys("new", "old", "older"))
+	assert.Equal(t, "new", x.Key)
+	assert.Equal(t, []string{"old", "older"}, x.RotatedKeys)
+	assert.Equal(t, csrf.New(csrf.SetKey("new")).Tokenize("salt"), x.Tokenize("salt"))
+}
+
+func TestEmptyKey(t *testing.T) {
+	for _, opt := range []csrf.Option{csrf.SetKey(""), csrf.SetKeys(), csrf.SetKeys("new", "")} {
+		x := &csrf.Csrf{Path: "/", Secure: true}
+		opt(x)
+		err := x.Validate()
+		assert.ErrorIs(t, err, csrf.ErrEmptyKey)
+		assert.ErrorIs(t, err, csrf.ErrInvalidConfig)
+	}
+	assert.PanicsWithError(t, "csrf: invalid configuration: csrf: secret key cannot be empty", func() {
+		csrf.New()
+	})
+}
+
+func TestKeyless(t *testing.T) {
+	// Origin/Fetch Metadata checks and synchronizer tokens use no key
+	x := csrf.New(csrf.SetAllowedOrigins("https://app.example.com"), csrf.SetCheckToken(false))
+	assert.NoError(t, verifyWith(x, ut.Header{Key: "Origin", Value: "https://app.example.com"}))
+	assert.ErrorIs(t, verifyWith(x, ut.Header{Key: "Origin", Value: "https://evil.com"}), csrf.ErrInvalidOrigin)
+
+	assert.NotPanics(t, func() {
+		csrf.New(csrf.SetSession(func(c *app.RequestContext) string { return "alice" }),
+			csrf.SetStore(csrf.NewStore(nil)))
+	})
+}
+
+func TestVerify_RotatedKeys(t *testing.T) {
+	salt := "abcd1234abcd1234"
+	cookie := ut.Header{Key: "Cookie", Value: "XSRF-SALT=" + salt}
+	old := csrf.New(csrf.SetKey("old"))
+	x := csrf.New(csrf.SetKeys("new", "old"))
+
+	assert.NoError(t, verifyWith(x, cookie, ut.Header{Key: csrf.DefaultHeaderName, Value: old.Tokenize(salt)}))
+	assert.NoError(t, verifyWith(x, cookie, ut.Header{Key: csrf.DefaultHeaderName, Value: x.Tokenize(salt)}))
+	// Once the old key is dropped its tokens are rejected
+	assert.ErrorIs(t, verifyWith(csrf.New(csrf.SetKey("new")), cookie,
+		ut.Header{Key: csrf.DefaultHeaderName, Value: old.Tokenize(salt)}), csrf.ErrInvalidToken)
+	assert.ErrorIs(t, verifyWith(x, cookie,
+		ut.Header{Key: csrf.DefaultHeaderName, Value: csrf.New(csrf.SetKey("other")).Tokenize(salt)}), csrf.ErrInvalidToken)
+
+	// Session-bound tokens
+	session := csrf.SetSession(func(c *app.RequestContext) string { return "alice" })
+	old = csrf.New(csrf.SetKey("old"), session)
+	x = csrf.New(csrf.SetKeys("new", "old"), session)
+	token := old.TokenizeFor(salt, "alice", time.Now())
+	assert.NoError(t, verifyWith(x, cookie, ut.Header{Key: csrf.DefaultHeaderName, Value: token}))
+	assert.ErrorIs(t, verifyWith(csrf.New(csrf.SetKey("new"), session), cookie,
+		ut.Header{Key: csrf.DefaultHeaderName, Value: token}), csrf.ErrInvalidToken)
+}
@@ -0,0 +1,119 @@
+package csrf
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// Errors returned by origin and Fetch Metadata checks.
+var (
+	ErrMissingOrigin    = errors.New("csrf: missing origin and referer")
+	ErrInvalidOrigin    = errors.New("csrf: origin not allowed")
+	ErrInvalidReferer   = errors.New("csrf: referer not allowed")
+	ErrInvalidFetchSite = errors.New("csrf: sec-fetch-site not allowed")
+	ErrInvalidFetchMode = errors.New("csrf: sec-fetch-mode not allowed")
+)
+
+// SetAllowedOrigins enables the Origin/Referer check with the given allow-list.
+// Entries are origins such as "https://app.example.com"; a leading "*." in the
+// host matches any subdomain, e.g. "https://*.example.com".
+// Requests without Origin and Referer are rejected with ErrMissingOrigin.
+func SetAllowedOrigins(origins ...string) Option {
+	return func(x *Csrf) {
+		x.AllowedOrigins = origins
+	}
+}
+
+// SetFetchSites enables the Sec-Fetch-Site check with the accepted values,
+// e.g. "same-origin", "same-site", "none". Requests without the header
+// (older browsers, non-browser clients) are left to the other checks.
+func SetFetchSites(sites ...string) Option {
+	return func(x *Csrf) {
+		x.FetchSites = sites
+	}
+}
+
+// SetFetchModes enables the Sec-Fetch-Mode check with the accepted values,
+// e.g. "cors", "same-origin". Requests without the header are left to the other checks.
+func SetFetchModes(modes ...string) Option {
+	return func(x *Csrf) {
+		x.FetchModes = modes
+	}
+}
+
+// SetCheckToken enables or disables the double-submit token check.
+// Disable it to rely on the Origin/Referer and Fetch Metadata checks only;
+// New panics if none of them is configured either.
+func SetCheckToken(v bool) Option {
+	return func(x *Csrf) {
+		x.SkipToken = !v
+	}
+}
+
+// VerifyOrigin checks the Origin header, or the Referer header if Origin is
+// absent, against AllowedOrigins. It does nothing if AllowedOrigins is empty.
+// Returns ErrMissingOrigin, ErrInvalidOrigin or ErrInvalidReferer.
+func (x *Csrf) VerifyOrigin(c *app.RequestContext) error {
+	if len(x.AllowedOrigins) == 0 {
+		return nil
+	}
+	if origin := string(c.GetHeader("Origin")); origin != "" {
+		if !x.allowOrigin(origin) {
+			return ErrInvalidOrigin
+		}
+		return nil
+	}
+	referer := string(c.GetHeader("Referer"))
+	if referer == "" {
+		return ErrMissingOrigin
+	}
+	u, err := url.Parse(referer)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ErrInvalidReferer
+	}
+	if !x.allowOrigin(u.Scheme + "://" + u.Host) {
+		return ErrInvalidReferer
+	}
+	return nil
+}
+
+// allowOrigin reports whether the origin matches the allow-list.
+func (x *Csrf) allowOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, v := range x.AllowedOrigins {
+		v = strings.ToLower(strings.TrimSuffix(v, "/"))
+		if v == origin {
+			return true
+		}
+		scheme, host, ok := strings.Cut(v, "://*.")
+		if !ok {
+			continue
+		}
+		// "https://*.example.com" matches "https://a.example.com", not "https://example.com"
+		if rest, ok := strings.CutPrefix(origin, scheme+"://"); ok && strings.HasSuffix(rest, "."+host) {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyFetchMetadata checks the Sec-Fetch-Site and Sec-Fetch-Mode headers
+// against FetchSites and FetchModes. Empty lists and absent headers are not checked.
+// Returns ErrInvalidFetchSite or ErrInvalidFetchMode.
+func (x *Csrf) VerifyFetchMetadata(c *app.RequestContext) error {
+	if site := string(c.GetHeader("Sec-Fetch-Site")); site != "" && len(x.FetchSites) != 0 {
+		if !slices.Contains(x.FetchSites, site) {
+			return ErrInvalidFetchSite
+		}
+	}
+	if mode := string(c.GetHeader("Sec-Fetch-Mode")); mode != "" && len(x.FetchModes) != 0 {
+		if !slices.Contains(x.FetchModes, mode) {
+			return ErrInvalidFetchMode
+		}
+	}
+	return nil
+}
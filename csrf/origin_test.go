@@ -0,0 +1,93 @@
+package csrf_test
+
+import (
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/kainonly/go/csrf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyOrigin(t *testing.T) {
+	x := csrf.New(
+		csrf.SetCheckToken(false),
+		csrf.SetAllowedOrigins("https://app.example.com", "https://*.example.org"),
+	)
+
+	assert.NoError(t, verifyWith(x, ut.Header{Key: "Origin", Value: "https://app.example.com"}))
+	assert.NoError(t, verifyWith(x, ut.Header{Key: "Origin", Value: "https://A.example.org"}))
+	assert.ErrorIs(t, verifyWith(x, ut.Header{Key: "Origin", Value: "https://example.org"}), csrf.ErrInvalidOrigin)
+	assert.ErrorIs(t, verifyWith(x, ut.Header{Key: "Origin", Value: "http://app.example.com"}), csrf.ErrInvalidOrigin)
+	assert.ErrorIs(t, verifyWith(x, ut.Header{Key: "Origin", Value: "https://app.example.com.evil.com"}), csrf.ErrInvalidOrigin)
+	assert.ErrorIs(t, verifyWith(x, ut.Header{Key: "Origin", Value: "null"}), csrf.ErrInvalidOrigin)
+
+	// Referer is the fallback when Origin is absent
+	assert.NoError(t, verifyWith(x, ut.Header{Key: "Referer", Value: "https://app.example.com/orders?id=1"}))
+	assert.ErrorIs(t, verifyWith(x, ut.Header{Key: "Referer", Value: "https://evil.com/app.example.com"}), csrf.ErrInvalidReferer)
+	assert.ErrorIs(t, verifyWith(x, ut.Header{Key: "Referer", Value: "/relative"}), csrf.ErrInvalidReferer)
+
+	assert.ErrorIs(t, verifyWith(x), csrf.ErrMissingOrigin)
+}
+
+func TestVerifyFetchMetadata(t *testing.T) {
+	x := csrf.New(
+		csrf.SetCheckToken(false),
+		csrf.SetFetchSites("same-origin", "none"),
+		csrf.SetFetchModes("cors", "same-origin"),
+	)
+
+	assert.NoError(t, verifyWith(x,
+		ut.Header{Key: "Sec-Fetch-Site", Value: "same-origin"},
+		ut.Header{Key: "Sec-Fetch-Mode", Value: "cors"}))
+	assert.ErrorIs(t, verifyWith(x, ut.Header{Key: "Sec-Fetch-Site", Value: "cross-site"}), csrf.ErrInvalidFetchSite)
+	assert.ErrorIs(t, verifyWith(x,
+		ut.Header{Key: "Sec-Fetch-Site", Value: "same-origin"},
+		ut.Header{Key: "Sec-Fetch-Mode", Value: "navigate"}), csrf.ErrInvalidFetchMode)
+
+	// Clients without Fetch Metadata are not rejected
+	assert.NoError(t, verifyWith(x))
+}
+
+func TestValidate_NoCheck(t *testing.T) {
+	assert.PanicsWithError(t, "csrf: invalid configuration: no check enabled, set allowed origins or fetch metadata without the token check", func() {
+		csrf.New(csrf.SetCheckToken(false))
+	})
+	assert.NotPanics(t, func() {
+		csrf.New(csrf.SetCheckToken(false), csrf.SetFetchSites("same-origin"))
+	})
+}
+
+func TestVerify_StructLiteral(t *testing.T) {
+	// The token check is on unless SkipToken is set
+	x := &csrf.Csrf{
+		Key:           "secret",
+		CookieName:    csrf.DefaultCookieName,
+		SaltName:      csrf.DefaultSaltName,
+		HeaderName:    csrf.DefaultHeaderName,
+		IgnoreMethods: map[string]bool{"GET": true},
+	}
+	assert.ErrorIs(t, verifyWith(x), csrf.ErrMissingSalt)
+	salt := "abcd1234abcd1234"
+	assert.NoError(t, verifyWith(x, ut.Header{Key: "Cookie", Value: "XSRF-SALT=" + salt},
+		ut.Header{Key: csrf.DefaultHeaderName, Value: x.Tokenize(salt)}))
+}
+
+func TestVerify_Combined(t *testing.T) {
+	x := csrf.New(
+		csrf.SetKey("secret"),
+		csrf.SetAllowedOrigins("https://app.example.com"),
+		csrf.SetFetchSites("same-origin"),
+	)
+	salt := "abcd1234abcd1234"
+	origin := ut.Header{Key: "Origin", Value: "https://app.example.com"}
+	cookie := ut.Header{Key: "Cookie", Value: "XSRF-SALT=" + salt}
+	token := ut.Header{Key: "X-XSRF-TOKEN", Value: x.Tokenize(salt)}
+
+	assert.NoError(t, verifyWith(x, origin, cookie, token))
+	// The token is still required
+	assert.ErrorIs(t, verifyWith(x, origin, cookie), csrf.ErrMissingHeader)
+	// Fetch Metadata is checked first, then the origin
+	assert.ErrorIs(t, verifyWith(x, ut.Header{Key: "Sec-Fetch-Site", Value: "cross-site"},
+		ut.Header{Key: "Origin", Value: "https://evil.com"}, cookie, token), csrf.ErrInvalidFetchSite)
+	assert.ErrorIs(t, verifyWith(x, ut.Header{Key: "Origin", Value: "https://evil.com"}, cookie, token), csrf.ErrInvalidOrigin)
+}
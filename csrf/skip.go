@@ -0,0 +1,70 @@
+package csrf
+
+import (
+	"path"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// SetExemptPaths exempts requests whose path matches one of the patterns.
+// Patterns use path.Match syntax ("/webhooks/*" matches one segment);
+// a trailing "/**" matches the prefix and everything below it.
+//
+//	csrf.SetExemptPaths("/webhooks/**", "/oauth/*/callback")
+func SetExemptPaths(patterns ...string) Option {
+	return func(x *Csrf) {
+		x.ExemptPaths = patterns
+	}
+}
+
+// SetSkipper sets a function that exempts a request when it returns true.
+func SetSkipper(v func(c *app.RequestContext) bool) Option {
+	return func(x *Csrf) {
+		x.Skipper = v
+	}
+}
+
+// SetExemptBearer exempts requests carrying an "Authorization: Bearer" header.
+// Browsers never attach this header to cross-site requests on their own, so
+// such requests cannot be forged. Only enable it if those routes authenticate
+// exclusively by the bearer token, never by cookies.
+func SetExemptBearer(v bool) Option {
+	return func(x *Csrf) {
+		x.ExemptBearer = v
+	}
+}
+
+// Skip reports whether the request is exempt from verification:
+// an ignored method, an exempt path, a bearer token (with ExemptBearer)
+// or the Skipper returning true.
+func (x *Csrf) Skip(c *app.RequestContext) bool {
+	if x.IgnoreMethods[string(c.Method())] {
+		return true
+	}
+	if x.matchPath(string(c.Path())) {
+		return true
+	}
+	if x.ExemptBearer {
+		v := string(c.GetHeader("Authorization"))
+		if len(v) > 7 && strings.EqualFold(v[:7], "Bearer ") {
+			return true
+		}
+	}
+	return x.Skipper != nil && x.Skipper(c)
+}
+
+func (x *Csrf) matchPath(p string) bool {
+	for _, pattern := range x.ExemptPaths {
+		if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+			if p == prefix || strings.HasPrefix(p, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package csrf_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/kainonly/go/csrf"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSkipRouter(x *csrf.Csrf) *route.Engine {
+	return newRouter(x, "/webhooks/github", "/webhooks/stripe/events", "/oauth/google/callback", "/api/orders", "/webhooksx")
+}
+
+func TestSkip_ExemptPaths(t *testing.T) {
+	router := newSkipRouter(csrf.New(
+		csrf.SetKey("secret"),
+		csrf.SetExemptPaths("/webhooks/**", "/oauth/*/callback"),
+	))
+
+	assert.Equal(t, http.StatusOK, post(router, "/webhooks/github"))
+	assert.Equal(t, http.StatusOK, post(router, "/webhooks/stripe/events"))
+	assert.Equal(t, http.StatusOK, post(router, "/oauth/google/callback"))
+	assert.Equal(t, http.StatusForbidden, post(router, "/webhooksx"))
+	assert.Equal(t, http.StatusForbidden, post(router, "/api/orders"))
+}
+
+func TestSkip_Skipper(t *testing.T) {
+	router := newSkipRouter(csrf.New(
+		csrf.SetKey("secret"),
+		csrf.SetSkipper(func(c *app.RequestContext) bool {
+			return string(c.GetHeader("X-Internal")) == "1"
+		}),
+	))
+
+	assert.Equal(t, http.StatusOK, post(router, "/api/orders", ut.Header{Key: "X-Internal", Value: "1"}))
+	assert.Equal(t, http.StatusForbidden, post(router, "/api/orders"))
+}
+
+func TestSkip_ExemptBearer(t *testing.T) {
+	router := newSkipRouter(csrf.New(csrf.SetKey("secret"), csrf.SetExemptBearer(true)))
+	assert.Equal(t, http.StatusOK, post(router, "/api/orders", ut.Header{Key: "Authorization", Value: "Bearer token"}))
+	assert.Equal(t, http.StatusForbidden, post(router, "/api/orders", ut.Header{Key: "Authorization", Value: "Basic dXNlcjpwYXNz"}))
+
+	// Disabled by default
+	router = newSkipRouter(csrf.New(csrf.SetKey("secret")))
+	assert.Equal(t, http.StatusForbidden, post(router, "/api/orders", ut.Header{Key: "Authorization", Value: "Bearer token"}))
+}
@@ -0,0 +1,42 @@
+package csrf
+
+import (
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// SetFormField sets the form field carrying the token for HTML form posts.
+// Both application/x-www-form-urlencoded and multipart/form-data bodies are read.
+// Default is "_csrf"; an empty string disables form lookup.
+func SetFormField(v string) Option {
+	return func(x *Csrf) {
+		x.FormField = v
+	}
+}
+
+// SetQueryParam sets the query parameter carrying the token. Disabled by default:
+// tokens in URLs can leak through logs, history and the Referer header.
+func SetQueryParam(v string) Option {
+	return func(x *Csrf) {
+		x.QueryParam = v
+	}
+}
+
+// Token returns the submitted token, or nil if the request carries none.
+// Sources are checked in order: the HeaderName header, the FormField form
+// field and the QueryParam query parameter; the first present one wins.
+func (x *Csrf) Token(c *app.RequestContext) []byte {
+	if v := c.GetHeader(x.HeaderName); v != nil {
+		return v
+	}
+	if x.FormField != "" {
+		if v, ok := c.GetPostForm(x.FormField); ok {
+			return []byte(v)
+		}
+	}
+	if x.QueryParam != "" {
+		if v, ok := c.GetQuery(x.QueryParam); ok {
+			return []byte(v)
+		}
+	}
+	return nil
+}
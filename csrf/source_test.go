@@ -0,0 +1,57 @@
+package csrf_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/kainonly/go/csrf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToken_Sources(t *testing.T) {
+	x := csrf.New(csrf.SetKey("secret"), csrf.SetQueryParam("csrf"))
+	header := ut.Header{Key: csrf.DefaultHeaderName, Value: "from-header"}
+
+	body, ct := form(url.Values{csrf.DefaultFormField: {"from-form"}})
+	assert.Equal(t, "from-form", tokenOf(x, "/api?csrf=from-query", body, ct))
+	body, ct = form(url.Values{csrf.DefaultFormField: {"from-form"}})
+	assert.Equal(t, "from-header", tokenOf(x, "/api?csrf=from-query", body, ct, header))
+	assert.Equal(t, "from-query", tokenOf(x, "/api?csrf=from-query", nil))
+	assert.Equal(t, "", tokenOf(x, "/api", nil))
+
+	// Multipart bodies
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	assert.NoError(t, w.WriteField(csrf.DefaultFormField, "from-multipart"))
+	file, err := w.CreateFormFile("file", "a.txt")
+	assert.NoError(t, err)
+	_, _ = file.Write([]byte("content"))
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "from-multipart", tokenOf(x, "/api", &ut.Body{Body: &buf, Len: buf.Len()},
+		ut.Header{Key: "Content-Type", Value: w.FormDataContentType()}))
+}
+
+func TestToken_Disabled(t *testing.T) {
+	x := csrf.New(csrf.SetKey("secret"), csrf.SetFormField(""))
+	body, ct := form(url.Values{csrf.DefaultFormField: {"from-form"}})
+	assert.Equal(t, "", tokenOf(x, "/api?_csrf=from-query", body, ct))
+}
+
+func TestVerifyToken_FormField(t *testing.T) {
+	x := csrf.New(csrf.SetKey("secret"))
+	router := newRouter(x)
+	salt := "abcd1234abcd1234"
+	cookie := ut.Header{Key: "Cookie", Value: "XSRF-SALT=" + salt}
+
+	body, ct := form(url.Values{csrf.DefaultFormField: {x.Tokenize(salt)}, "amount": {"100"}})
+	w := ut.PerformRequest(router, "POST", "/api", body, ct, cookie)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode())
+
+	body, ct = form(url.Values{csrf.DefaultFormField: {"invalid"}})
+	w = ut.PerformRequest(router, "POST", "/api", body, ct, cookie)
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode())
+}
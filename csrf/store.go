@@ -0,0 +1,141 @@
+package csrf
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kainonly/go/help"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultStoreTokenLength is the length of synchronizer tokens.
+const DefaultStoreTokenLength = 32
+
+// ErrMissingSession is returned when a synchronizer token is issued or checked
+// without a session identifier.
+var ErrMissingSession = errors.New("csrf: missing session")
+
+// checkAndDelete deletes the stored token only if it matches, so a single-use
+// token cannot be consumed twice by concurrent requests.
+var checkAndDelete = redis.NewScript(`
+local v = redis.call('GET', KEYS[1])
+if not v then return -1 end
+if v ~= ARGV[1] then return 0 end
+redis.call('DEL', KEYS[1])
+return 1
+`)
+
+// Store keeps synchronizer tokens in Redis, one per session.
+type Store struct {
+	// RDb is the Redis client for storing tokens.
+	RDb *redis.Client
+	// Prefix is the key prefix for all token keys (default: "csrf").
+	Prefix string
+	// TTL is the lifetime of a stored token (default: 24h).
+	TTL time.Duration
+	// SingleUse deletes a token once it is verified (default: false).
+	SingleUse bool
+}
+
+// NewStore creates a Store with the given Redis client.
+func NewStore(rdb *redis.Client, options ...StoreOption) *Store {
+	x := &Store{
+		RDb:    rdb,
+		Prefix: "csrf",
+		TTL:    24 * time.Hour,
+	}
+	for _, opt := range options {
+		opt(x)
+	}
+	return x
+}
+
+// StoreOption is a function that configures a Store instance.
+type StoreOption func(x *Store)
+
+// SetStorePrefix sets the Redis key prefix.
+// Default is "csrf", resulting in keys like "csrf:{session}".
+func SetStorePrefix(v string) StoreOption {
+	return func(x *Store) {
+		x.Prefix = v
+	}
+}
+
+// SetStoreTTL sets the lifetime of stored tokens.
+func SetStoreTTL(v time.Duration) StoreOption {
+	return func(x *Store) {
+		x.TTL = v
+	}
+}
+
+// SetSingleUse makes every token valid for one verification only.
+// VerifyToken issues a fresh token cookie after each successful check.
+func SetSingleUse(v bool) StoreOption {
+	return func(x *Store) {
+		x.SingleUse = v
+	}
+}
+
+// SetStore enables synchronizer-token mode: tokens are random values stored in
+// Redis per session instead of HMACs of the salt cookie. Requires SetSession.
+func SetStore(v *Store) Option {
+	return func(x *Csrf) {
+		x.Store = v
+	}
+}
+
+// Key generates the full Redis key for a session.
+// Format: "{prefix}:{session}"
+func (x *Store) Key(session string) string {
+	return fmt.Sprintf("%s:%s", x.Prefix, session)
+}
+
+// Issue generates a token for the session, replacing the previous one.
+func (x *Store) Issue(ctx context.Context, session string) (string, error) {
+	if session == "" {
+		return "", ErrMissingSession
+	}
+	token := help.Random(DefaultStoreTokenLength)
+	if err := x.RDb.Set(ctx, x.Key(session), token, x.TTL).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Check compares the token with the one stored for the session.
+// With SingleUse, a matching token is deleted.
+// Returns ErrMissingSession or ErrInvalidToken.
+func (x *Store) Check(ctx context.Context, session string, token string) error {
+	if session == "" {
+		return ErrMissingSession
+	}
+	if x.SingleUse {
+		r, err := checkAndDelete.Run(ctx, x.RDb, []string{x.Key(session)}, token).Int()
+		if err != nil {
+			return err
+		}
+		if r != 1 {
+			return ErrInvalidToken
+		}
+		return nil
+	}
+	stored, err := x.RDb.Get(ctx, x.Key(session)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return ErrInvalidToken
+		}
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(token)) != 1 {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// Revoke deletes the token of the session, e.g. on logout.
+func (x *Store) Revoke(ctx context.Context, session string) error {
+	return x.RDb.Del(ctx, x.Key(session)).Err()
+}
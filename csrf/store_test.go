@@ -0,0 +1,110 @@
+package csrf_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/kainonly/go/csrf"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_Key(t *testing.T) {
+	assert.Equal(t, "csrf:alice", csrf.NewStore(nil).Key("alice"))
+	assert.Equal(t, "xsrf:alice", csrf.NewStore(nil, csrf.SetStorePrefix("xsrf")).Key("alice"))
+}
+
+func TestStore_RequiresSession(t *testing.T) {
+	x := &csrf.Csrf{Path: "/", Secure: true, Store: csrf.NewStore(nil)}
+	assert.ErrorIs(t, x.Validate(), csrf.ErrInvalidConfig)
+	assert.Panics(t, func() {
+		csrf.New(csrf.SetKey("secret"), csrf.SetStore(csrf.NewStore(nil)))
+	})
+}
+
+func TestStore_IssueCheck(t *testing.T) {
+	ctx := context.TODO()
+	store := newStore(t, csrf.SetStoreTTL(time.Minute))
+	token, err := store.Issue(ctx, "alice")
+	assert.NoError(t, err)
+	assert.Len(t, token, csrf.DefaultStoreTokenLength)
+	assert.InDelta(t, time.Minute, store.RDb.TTL(ctx, store.Key("alice")).Val(), float64(time.Second))
+
+	assert.NoError(t, store.Check(ctx, "alice", token))
+	assert.NoError(t, store.Check(ctx, "alice", token))
+	assert.ErrorIs(t, store.Check(ctx, "bob", token), csrf.ErrInvalidToken)
+	assert.ErrorIs(t, store.Check(ctx, "alice", "invalid"), csrf.ErrInvalidToken)
+	assert.ErrorIs(t, store.Check(ctx, "", token), csrf.ErrMissingSession)
+
+	_, err = store.Issue(ctx, "")
+	assert.ErrorIs(t, err, csrf.ErrMissingSession)
+
+	// A new token replaces the previous one
+	next, err := store.Issue(ctx, "alice")
+	assert.NoError(t, err)
+	assert.ErrorIs(t, store.Check(ctx, "alice", token), csrf.ErrInvalidToken)
+	assert.NoError(t, store.Check(ctx, "alice", next))
+
+	assert.NoError(t, store.Revoke(ctx, "alice"))
+	assert.ErrorIs(t, store.Check(ctx, "alice", next), csrf.ErrInvalidToken)
+}
+
+func TestStore_SingleUse(t *testing.T) {
+	ctx := context.TODO()
+	store := newStore(t, csrf.SetSingleUse(true))
+	token, err := store.Issue(ctx, "alice")
+	assert.NoError(t, err)
+	assert.ErrorIs(t, store.Check(ctx, "alice", "invalid"), csrf.ErrInvalidToken)
+	// A wrong token does not consume the stored one
+	assert.NoError(t, store.Check(ctx, "alice", token))
+	assert.ErrorIs(t, store.Check(ctx, "alice", token), csrf.ErrInvalidToken)
+}
+
+func TestVerifyToken_Store(t *testing.T) {
+	x := csrf.New(csrf.SetKey("secret"), csrf.SetSession(userSession), csrf.SetStore(newStore(t)))
+	router := newRouter(x)
+
+	token := issue(router, "alice")
+	assert.NotEmpty(t, token)
+	assert.Equal(t, http.StatusOK, postWithToken(router, "", token, "alice"))
+	assert.Equal(t, http.StatusOK, postWithToken(router, "", token, "alice"))
+	assert.Equal(t, http.StatusForbidden, postWithToken(router, "", token, "bob"))
+	assert.Equal(t, http.StatusForbidden, postWithToken(router, "", token, ""))
+}
+
+func TestVerifyToken_StoreSingleUse(t *testing.T) {
+	x := csrf.New(csrf.SetKey("secret"), csrf.SetSession(userSession),
+		csrf.SetStore(newStore(t, csrf.SetSingleUse(true))))
+	router := newRouter(x)
+
+	token := issue(router, "alice")
+	w := request(router, "POST", "/api",
+		ut.Header{Key: "X-User", Value: "alice"},
+		ut.Header{Key: csrf.DefaultHeaderName, Value: token})
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode())
+	next := cookieValue(w, csrf.DefaultCookieName)
+	assert.NotEmpty(t, next)
+	assert.NotEqual(t, token, next)
+
+	// Replayed token is rejected, the rotated one is accepted
+	assert.Equal(t, http.StatusForbidden, postWithToken(router, "", token, "alice"))
+	assert.Equal(t, http.StatusOK, postWithToken(router, "", next, "alice"))
+}
+
+func TestVerifyToken_StoreServerError(t *testing.T) {
+	// Nothing listens on this address
+	store := csrf.NewStore(redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1}))
+	x := csrf.New(csrf.SetKey("secret"), csrf.SetSession(userSession), csrf.SetStore(store),
+		csrf.SetErrorHandler(func(ctx context.Context, c *app.RequestContext, err error, code int64) {
+			t.Fatal("error handler must not run")
+		}))
+	w := request(newRouter(x), "POST", "/api",
+		ut.Header{Key: "X-User", Value: "alice"},
+		ut.Header{Key: csrf.DefaultHeaderName, Value: "token"})
+	assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode())
+	assert.NotContains(t, string(w.Result().Body()), "127.0.0.1")
+}
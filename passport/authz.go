@@ -0,0 +1,242 @@
+package passport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/kainonly/go/help"
+)
+
+// Errors returned by authorization policies.
+var (
+	ErrInsufficientScope = errors.New("passport: insufficient scope")
+	ErrInsufficientRole  = errors.New("passport: insufficient role")
+)
+
+// Policy decides whether the claims are authorized.
+// It returns nil to allow the request, or an error to reject it with 403.
+type Policy func(claims Claims) error
+
+// Authorizer checks scopes and roles carried in Claims.Data.
+//
+// Scopes are read from Data["scope"] as a space-delimited string (OAuth style)
+// or a list of strings. Roles are read from Data["role"] as a string or a list
+// of strings; with a role hierarchy, a higher role satisfies lower ones.
+//
+//	authz := passport.NewAuthorizer(passport.SetRoles(map[string]int{
+//		"viewer": 1,
+//		"editor": 2,
+//		"admin":  3,
+//	}))
+//
+//	api := h.Group("/api", authn.Authenticate())
+//	api.GET("/orders", authz.RequireAnyScope("orders:read", "orders:write"), listOrders)
+//
+//	// Group level policy - every route requires editor or above
+//	admin := api.Group("/admin", authz.RequireRole("editor"))
+//	admin.DELETE("/users/:id", authz.RequireRole("admin"), deleteUser)
+//
+// Authorizer runs after Authenticate. Requests without claims are rejected
+// with 401, unauthorized requests with 403 and a help.R body.
+type Authorizer struct {
+	// ScopeKey is the Data key holding scopes (default: "scope").
+	ScopeKey string
+	// RoleKey is the Data key holding roles (default: "role").
+	RoleKey string
+	// Roles maps role names to hierarchy levels (default: nil, roles match exactly).
+	Roles map[string]int
+	// Code is the error code in the response body (default: 0).
+	Code int64
+}
+
+// NewAuthorizer creates a new Authorizer.
+func NewAuthorizer(options ...AuthzOption) *Authorizer {
+	x := &Authorizer{
+		ScopeKey: "scope",
+		RoleKey:  "role",
+	}
+	for _, opt := range options {
+		opt(x)
+	}
+	return x
+}
+
+// AuthzOption is a function that configures an Authorizer instance.
+type AuthzOption func(x *Authorizer)
+
+// SetScopeKey sets the Data key holding scopes.
+func SetScopeKey(v string) AuthzOption {
+	return func(x *Authorizer) {
+		x.ScopeKey = v
+	}
+}
+
+// SetRoleKey sets the Data key holding roles.
+func SetRoleKey(v string) AuthzOption {
+	return func(x *Authorizer) {
+		x.RoleKey = v
+	}
+}
+
+// SetRoles sets the role hierarchy. Higher levels include lower ones.
+func SetRoles(v map[string]int) AuthzOption {
+	return func(x *Authorizer) {
+		x.Roles = v
+	}
+}
+
+// SetForbiddenCode sets the error code returned in the response body.
+func SetForbiddenCode(v int64) AuthzOption {
+	return func(x *Authorizer) {
+		x.Code = v
+	}
+}
+
+// Scopes returns the scopes of the claims.
+func (x *Authorizer) Scopes(claims Claims) []string {
+	return stringsOf(claims.Data[x.ScopeKey])
+}
+
+// RolesOf returns the roles of the claims.
+func (x *Authorizer) RolesOf(claims Claims) []string {
+	return stringsOf(claims.Data[x.RoleKey])
+}
+
+// Level returns the highest hierarchy level of the claims' roles,
+// or -1 if none of them is in the hierarchy.
+func (x *Authorizer) Level(claims Claims) int {
+	level := -1
+	for _, role := range x.RolesOf(claims) {
+		if v, ok := x.Roles[role]; ok && v > level {
+			level = v
+		}
+	}
+	return level
+}
+
+// AnyScope returns a Policy that requires at least one of the scopes.
+func (x *Authorizer) AnyScope(scopes ...string) Policy {
+	return func(claims Claims) error {
+		granted := x.Scopes(claims)
+		for _, v := range scopes {
+			if slices.Contains(granted, v) {
+				return nil
+			}
+		}
+		return ErrInsufficientScope
+	}
+}
+
+// AllScopes returns a Policy that requires all of the scopes.
+func (x *Authorizer) AllScopes(scopes ...string) Policy {
+	return func(claims Claims) error {
+		granted := x.Scopes(claims)
+		for _, v := range scopes {
+			if !slices.Contains(granted, v) {
+				return ErrInsufficientScope
+			}
+		}
+		return nil
+	}
+}
+
+// MinRole returns a Policy that requires the role or one above it in the hierarchy.
+// Roles missing from the hierarchy must match exactly.
+func (x *Authorizer) MinRole(role string) Policy {
+	return func(claims Claims) error {
+		if required, ok := x.Roles[role]; ok {
+			if x.Level(claims) >= required {
+				return nil
+			}
+			return ErrInsufficientRole
+		}
+		if slices.Contains(x.RolesOf(claims), role) {
+			return nil
+		}
+		return ErrInsufficientRole
+	}
+}
+
+// MinLevel returns a Policy that requires a role hierarchy level of at least v.
+func (x *Authorizer) MinLevel(v int) Policy {
+	return func(claims Claims) error {
+		if x.Level(claims) >= v {
+			return nil
+		}
+		return ErrInsufficientRole
+	}
+}
+
+// Authorize evaluates the policies in order and returns the first error.
+func (x *Authorizer) Authorize(claims Claims, policies ...Policy) error {
+	for _, policy := range policies {
+		if err := policy(claims); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Require returns a Hertz middleware that requires all policies to pass.
+// Use it on a route or a route group.
+func (x *Authorizer) Require(policies ...Policy) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		claims, ok := GetClaims(c)
+		if !ok {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, help.Fail(x.Code, ErrMissingToken.Error()))
+			return
+		}
+		if err := x.Authorize(claims, policies...); err != nil {
+			if errors.Is(err, ErrInsufficientScope) {
+				c.Header("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+			}
+			c.AbortWithStatusJSON(http.StatusForbidden, help.Fail(x.Code, err.Error()))
+			return
+		}
+		c.Next(ctx)
+	}
+}
+
+// RequireAnyScope returns a Hertz middleware that requires at least one of the scopes.
+func (x *Authorizer) RequireAnyScope(scopes ...string) app.HandlerFunc {
+	return x.Require(x.AnyScope(scopes...))
+}
+
+// RequireAllScopes returns a Hertz middleware that requires all of the scopes.
+func (x *Authorizer) RequireAllScopes(scopes ...string) app.HandlerFunc {
+	return x.Require(x.AllScopes(scopes...))
+}
+
+// RequireRole returns a Hertz middleware that requires the role or one above it.
+func (x *Authorizer) RequireRole(role string) app.HandlerFunc {
+	return x.Require(x.MinRole(role))
+}
+
+// RequireLevel returns a Hertz middleware that requires a role hierarchy level of at least v.
+func (x *Authorizer) RequireLevel(v int) app.HandlerFunc {
+	return x.Require(x.MinLevel(v))
+}
+
+// stringsOf converts a claim value to a list of strings.
+// A string is split on whitespace.
+func stringsOf(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, s := range v {
+			values = append(values, fmt.Sprint(s))
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package passport_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/kainonly/go/passport"
+	"github.com/stretchr/testify/assert"
+)
+
+var roles = map[string]int{"viewer": 1, "editor": 2, "admin": 3}
+
+func claimsWith(data map[string]interface{}) passport.Claims {
+	return passport.Claims{ActiveId: userId1, Data: data}
+}
+
+func TestAuthorizer_Scopes(t *testing.T) {
+	authz := passport.NewAuthorizer()
+
+	oauth := claimsWith(map[string]interface{}{"scope": "orders:read orders:write"})
+	assert.Equal(t, []string{"orders:read", "orders:write"}, authz.Scopes(oauth))
+	assert.NoError(t, authz.Authorize(oauth, authz.AnyScope("users:read", "orders:read")))
+	assert.NoError(t, authz.Authorize(oauth, authz.AllScopes("orders:read", "orders:write")))
+	assert.ErrorIs(t, authz.Authorize(oauth, authz.AllScopes("orders:read", "users:read")), passport.ErrInsufficientScope)
+	assert.ErrorIs(t, authz.Authorize(oauth, authz.AnyScope("users:read")), passport.ErrInsufficientScope)
+
+	// Lists decoded from JSON
+	list := claimsWith(map[string]interface{}{"scope": []interface{}{"users:read"}})
+	assert.NoError(t, authz.Authorize(list, authz.AnyScope("users:read")))
+
+	// Custom key
+	authz = passport.NewAuthorizer(passport.SetScopeKey("permissions"))
+	custom := claimsWith(map[string]interface{}{"permissions": []string{"billing"}})
+	assert.NoError(t, authz.Authorize(custom, authz.AnyScope("billing")))
+	assert.ErrorIs(t, authz.Authorize(claimsWith(nil), authz.AnyScope("billing")), passport.ErrInsufficientScope)
+}
+
+func TestAuthorizer_Roles(t *testing.T) {
+	authz := passport.NewAuthorizer(passport.SetRoles(roles))
+
+	editor := claimsWith(map[string]interface{}{"role": "editor"})
+	assert.Equal(t, 2, authz.Level(editor))
+	assert.NoError(t, authz.Authorize(editor, authz.MinRole("viewer")))
+	assert.NoError(t, authz.Authorize(editor, authz.MinRole("editor")))
+	assert.ErrorIs(t, authz.Authorize(editor, authz.MinRole("admin")), passport.ErrInsufficientRole)
+	assert.NoError(t, authz.Authorize(editor, authz.MinLevel(2)))
+	assert.ErrorIs(t, authz.Authorize(editor, authz.MinLevel(3)), passport.ErrInsufficientRole)
+
+	// The highest of several roles counts
+	multi := claimsWith(map[string]interface{}{"role": []interface{}{"viewer", "admin"}})
+	assert.Equal(t, 3, authz.Level(multi))
+
+	// Roles outside the hierarchy match exactly
+	auditor := claimsWith(map[string]interface{}{"role": "auditor"})
+	assert.Equal(t, -1, authz.Level(auditor))
+	assert.NoError(t, authz.Authorize(auditor, authz.MinRole("auditor")))
+	assert.ErrorIs(t, authz.Authorize(auditor, authz.MinRole("viewer")), passport.ErrInsufficientRole)
+
+	// Policies are combined in order
+	assert.ErrorIs(t, authz.Authorize(editor, authz.MinRole("viewer"), authz.AnyScope("x")), passport.ErrInsufficientScope)
+}
+
+func TestAuthorizer_Middleware(t *testing.T) {
+	authn := passport.NewAuthenticator(x1)
+	authz := passport.NewAuthorizer(passport.SetRoles(roles), passport.SetForbiddenCode(403))
+
+	router := route.NewEngine(config.NewOptions([]config.Option{}))
+	ok := func(ctx context.Context, c *app.RequestContext) {
+		c.String(http.StatusOK, "ok")
+	}
+	admin := router.Group("/admin", authn.Authenticate(), authz.RequireRole("editor"))
+	admin.GET("/posts", ok)
+	admin.DELETE("/users", authz.RequireRole("admin"), ok)
+	router.GET("/orders", authn.Authenticate(), authz.RequireAnyScope("orders:read"), ok)
+	router.GET("/public", authz.RequireLevel(1), ok)
+
+	request := func(method string, path string, data map[string]interface{}) *ut.ResponseRecorder {
+		ts, err := x1.Create(passport.NewClaims(userId1, time.Hour).SetData(data))
+		assert.NoError(t, err)
+		return ut.PerformRequest(router, method, path, &ut.Body{Body: bytes.NewBuffer(nil)},
+			ut.Header{Key: "Authorization", Value: "Bearer " + ts})
+	}
+
+	w := request("GET", "/admin/posts", map[string]interface{}{"role": "editor"})
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode())
+
+	w = request("DELETE", "/admin/users", map[string]interface{}{"role": "editor"})
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode())
+	assert.JSONEq(t, `{"code":403,"message":"passport: insufficient role"}`, string(w.Result().Body()))
+
+	w = request("DELETE", "/admin/users", map[string]interface{}{"role": "admin"})
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode())
+
+	w = request("GET", "/admin/posts", map[string]interface{}{"role": "viewer"})
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode())
+
+	w = request("GET", "/orders", map[string]interface{}{"scope": "users:read"})
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode())
+	assert.Equal(t, `Bearer error="insufficient_scope"`, string(w.Result().Header.Peek("WWW-Authenticate")))
+
+	w = request("GET", "/orders", map[string]interface{}{"scope": "orders:read"})
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode())
+
+	// Without Authenticate there are no claims
+	w = ut.PerformRequest(router, "GET", "/public", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode())
+}
@@ -0,0 +1,300 @@
+package passport
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kainonly/go/help"
+	"github.com/redis/go-redis/v9"
+)
+
+// Errors returned by proof of possession functions.
+var (
+	ErrMissingProof     = errors.New("passport: missing DPoP proof")
+	ErrInvalidProof     = errors.New("passport: invalid DPoP proof")
+	ErrProofReplayed    = errors.New("passport: DPoP proof has already been used")
+	ErrProofKeyMismatch = errors.New("passport: DPoP proof key does not match the token binding")
+)
+
+// ProofHeader is the request header carrying the DPoP proof.
+const ProofHeader = "DPoP"
+
+// Confirmation is the cnf claim of a sender-constrained token.
+type Confirmation struct {
+	// JKT is the RFC 7638 thumbprint of the client public key.
+	JKT string `json:"jkt"`
+}
+
+// Proof is a verified DPoP proof.
+type Proof struct {
+	// JKT is the RFC 7638 thumbprint of the key that signed the proof.
+	JKT string
+	// Key is the public key that signed the proof.
+	Key crypto.PublicKey
+	// ID is the proof nonce (jti claim).
+	ID string
+	// IssuedAt is when the client created the proof.
+	IssuedAt time.Time
+}
+
+// proofClaims are the claims of a DPoP proof JWT (RFC 9449 section 4.2).
+type proofClaims struct {
+	Htm string `json:"htm"`
+	Htu string `json:"htu"`
+	Ath string `json:"ath,omitempty"`
+
+	jwt.RegisteredClaims
+}
+
+// ProofVerifier verifies DPoP proofs (RFC 9449) with Redis replay protection.
+//
+// The client holds a key pair and sends a proof JWT in the DPoP header of every
+// request. The proof is signed with the client key, carries the public key in
+// its header and covers the request method, URL, time, a nonce and the access
+// token hash. A token bound to the key thumbprint (cnf.jkt) is useless without
+// the private key, even if it leaks from localStorage.
+//
+//	proofs := passport.NewProofVerifier(redisClient)
+//
+//	// Login - bind the token to the key of the proof sent with the login request
+//	proof, err := proofs.Verify(ctx, string(c.GetHeader(passport.ProofHeader)), "POST", loginURL, "")
+//	token, err := auth.Create(passport.NewClaims(userId, time.Hour).SetConfirmation(proof.JKT))
+//
+//	// Protected routes - bound tokens require a matching proof
+//	authn := passport.NewAuthenticator(auth,
+//		passport.SetExtractors(passport.FromDPoP(), passport.FromBearer()),
+//		passport.SetProofVerifier(proofs),
+//	)
+type ProofVerifier struct {
+	// RDb is the Redis client for storing used proof nonces.
+	RDb *redis.Client
+	// Prefix is the key prefix for all proof keys (default: "passport:dpop").
+	Prefix string
+	// MaxAge is how long a proof is accepted after its iat (default: 1 minute).
+	MaxAge time.Duration
+	// Leeway is the allowed clock skew for proofs from the future (default: 5 seconds).
+	Leeway time.Duration
+	// Algorithms is the allow-list of proof signing algorithms
+	// (default: ES256, ES384, RS256, PS256, EdDSA).
+	Algorithms []string
+	// BaseURL replaces the scheme and host of the request URL when the server runs
+	// behind a proxy, e.g. "https://api.example.com" (default: taken from the request).
+	BaseURL string
+}
+
+// NewProofVerifier creates a new ProofVerifier with the given Redis client.
+func NewProofVerifier(rdb *redis.Client, options ...ProofOption) *ProofVerifier {
+	x := &ProofVerifier{
+		RDb:        rdb,
+		Prefix:     "passport:dpop",
+		MaxAge:     time.Minute,
+		Leeway:     5 * time.Second,
+		Algorithms: []string{"ES256", "ES384", "RS256", "PS256", "EdDSA"},
+	}
+	for _, opt := range options {
+		opt(x)
+	}
+	return x
+}
+
+// ProofOption is a function that configures a ProofVerifier instance.
+type ProofOption func(x *ProofVerifier)
+
+// SetProofPrefix sets the Redis key prefix for proof keys.
+func SetProofPrefix(v string) ProofOption {
+	return func(x *ProofVerifier) {
+		x.Prefix = v
+	}
+}
+
+// SetProofMaxAge sets how long a proof is accepted after its iat.
+func SetProofMaxAge(v time.Duration) ProofOption {
+	return func(x *ProofVerifier) {
+		x.MaxAge = v
+	}
+}
+
+// SetProofLeeway sets the allowed clock skew for proofs from the future.
+func SetProofLeeway(v time.Duration) ProofOption {
+	return func(x *ProofVerifier) {
+		x.Leeway = v
+	}
+}
+
+// SetProofAlgorithms sets the allow-list of proof signing algorithms.
+// Symmetric algorithms are never accepted.
+func SetProofAlgorithms(v ...string) ProofOption {
+	return func(x *ProofVerifier) {
+		x.Algorithms = v
+	}
+}
+
+// SetBaseURL sets the public scheme and host used to check the htu claim.
+func SetBaseURL(v string) ProofOption {
+	return func(x *ProofVerifier) {
+		x.BaseURL = strings.TrimSuffix(v, "/")
+	}
+}
+
+// Key generates the full Redis key for a proof name.
+// Format: "{prefix}:{name}"
+func (x *ProofVerifier) Key(name string) string {
+	return fmt.Sprintf("%s:%s", x.Prefix, name)
+}
+
+// Verify verifies a DPoP proof for the given request method and URL.
+// accessToken is the token sent with the proof; it is checked against the ath
+// claim unless empty (token requests). Each proof is accepted only once.
+// Returns ErrMissingProof, ErrInvalidProof (wrapped with the reason) or ErrProofReplayed.
+func (x *ProofVerifier) Verify(ctx context.Context, proof string, method string, htu string, accessToken string) (*Proof, error) {
+	if proof == "" {
+		return nil, ErrMissingProof
+	}
+	var claims proofClaims
+	var key crypto.PublicKey
+	if _, err := jwt.NewParser(
+		jwt.WithValidMethods(x.Algorithms),
+		jwt.WithoutClaimsValidation(),
+	).ParseWithClaims(proof, &claims, func(token *jwt.Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); typ != "dpop+jwt" {
+			return nil, errors.New("typ must be dpop+jwt")
+		}
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			return nil, ErrInvalidSigningMethod
+		}
+		var err error
+		key, err = proofKey(token.Header["jwk"])
+		return key, err
+	}); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidProof, err)
+	}
+
+	if claims.ID == "" || claims.IssuedAt == nil {
+		return nil, fmt.Errorf("%w: missing jti or iat", ErrInvalidProof)
+	}
+	if claims.Htm != method {
+		return nil, fmt.Errorf("%w: htm does not match", ErrInvalidProof)
+	}
+	if !sameURL(claims.Htu, htu) {
+		return nil, fmt.Errorf("%w: htu does not match", ErrInvalidProof)
+	}
+	if accessToken != "" {
+		sum := sha256.Sum256([]byte(accessToken))
+		if claims.Ath != b64.EncodeToString(sum[:]) {
+			return nil, fmt.Errorf("%w: ath does not match", ErrInvalidProof)
+		}
+	}
+	iat := claims.IssuedAt.Time
+	if now := time.Now(); iat.After(now.Add(x.Leeway)) || iat.Before(now.Add(-x.MaxAge)) {
+		return nil, fmt.Errorf("%w: iat outside the accepted window", ErrInvalidProof)
+	}
+
+	jkt, err := thumbprint(key)
+	if err != nil {
+		return nil, err
+	}
+	// The nonce is remembered as long as the proof could be accepted
+	ok, err := x.RDb.SetNX(ctx, x.Key("jti:"+help.Sha256hex(jkt+":"+claims.ID)), 1, x.MaxAge+x.Leeway).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrProofReplayed
+	}
+	return &Proof{JKT: jkt, Key: key, ID: claims.ID, IssuedAt: iat}, nil
+}
+
+// VerifyRequest verifies the DPoP proof of a Hertz request.
+// The htu is built from BaseURL (or the request scheme and host) and the request path.
+func (x *ProofVerifier) VerifyRequest(ctx context.Context, c *app.RequestContext, accessToken string) (*Proof, error) {
+	base := x.BaseURL
+	if base == "" {
+		base = string(c.URI().Scheme()) + "://" + string(c.Host())
+	}
+	return x.Verify(ctx, string(c.GetHeader(ProofHeader)), string(c.Method()),
+		base+string(c.URI().Path()), accessToken)
+}
+
+// Confirm verifies the DPoP proof of a request against a bound token.
+// Returns ErrProofKeyMismatch if the proof was signed by a different key.
+func (x *ProofVerifier) Confirm(ctx context.Context, c *app.RequestContext, accessToken string, cnf *Confirmation) error {
+	proof, err := x.VerifyRequest(ctx, c, accessToken)
+	if err != nil {
+		return err
+	}
+	if cnf == nil || cnf.JKT != proof.JKT {
+		return ErrProofKeyMismatch
+	}
+	return nil
+}
+
+// proofKey decodes the public key from the jwk header of a proof.
+func proofKey(v interface{}) (crypto.PublicKey, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("missing jwk header")
+	}
+	if _, ok = m["d"]; ok {
+		return nil, errors.New("jwk header contains a private key")
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var jwk JWK
+	if err = json.Unmarshal(b, &jwk); err != nil {
+		return nil, err
+	}
+	return jwk.PublicKey()
+}
+
+// sameURL compares two URLs ignoring query and fragment (RFC 9449 section 4.3).
+func sameURL(a string, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Scheme, ub.Scheme) &&
+		strings.EqualFold(ua.Host, ub.Host) &&
+		ua.EscapedPath() == ub.EscapedPath()
+}
+
+// CreateProof creates a DPoP proof signed with the client key.
+// accessToken is hashed into the ath claim unless empty.
+// It is intended for Go clients and tests; browsers create proofs with WebCrypto.
+func CreateProof(key crypto.Signer, method string, htu string, accessToken string) (string, error) {
+	jwk, err := NewJWK("", "", key.Public())
+	if err != nil {
+		return "", err
+	}
+	jwk.Use = ""
+	claims := proofClaims{
+		Htm: method,
+		Htu: htu,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       help.Uuid7(),
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+	}
+	if accessToken != "" {
+		sum := sha256.Sum256([]byte(accessToken))
+		claims.Ath = b64.EncodeToString(sum[:])
+	}
+	token := jwt.NewWithClaims(inferMethod(key.Public()), claims)
+	token.Header["typ"] = "dpop+jwt"
+	token.Header["jwk"] = jwk
+	return token.SignedString(key)
+}
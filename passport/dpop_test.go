@@ -0,0 +1,166 @@
+package passport_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kainonly/go/passport"
+	"github.com/stretchr/testify/assert"
+)
+
+const apiURL = "https://api.example.com/api"
+
+func newClientKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	jwk, err := passport.NewJWK("", "", key.Public())
+	assert.NoError(t, err)
+	return key, jwk.Thumbprint()
+}
+
+func TestProofVerifier_Verify(t *testing.T) {
+	requireRedis(t)
+	ctx := context.TODO()
+	proofs := passport.NewProofVerifier(rdb)
+	key, jkt := newClientKey(t)
+
+	proof, err := passport.CreateProof(key, "POST", "https://api.example.com/auth/login", "")
+	assert.NoError(t, err)
+	p, err := proofs.Verify(ctx, proof, "POST", "https://api.example.com/auth/login?next=1", "")
+	assert.NoError(t, err)
+	assert.Equal(t, jkt, p.JKT)
+	assert.NotEmpty(t, p.ID)
+
+	// Each proof is accepted once
+	_, err = proofs.Verify(ctx, proof, "POST", "https://api.example.com/auth/login", "")
+	assert.ErrorIs(t, err, passport.ErrProofReplayed)
+
+	_, err = proofs.Verify(ctx, "", "POST", apiURL, "")
+	assert.ErrorIs(t, err, passport.ErrMissingProof)
+}
+
+func TestProofVerifier_Mismatch(t *testing.T) {
+	requireRedis(t)
+	ctx := context.TODO()
+	proofs := passport.NewProofVerifier(rdb)
+	key, _ := newClientKey(t)
+
+	proof, _ := passport.CreateProof(key, "GET", apiURL, "token-a")
+	_, err := proofs.Verify(ctx, proof, "POST", apiURL, "token-a")
+	assert.ErrorIs(t, err, passport.ErrInvalidProof)
+
+	proof, _ = passport.CreateProof(key, "GET", apiURL, "token-a")
+	_, err = proofs.Verify(ctx, proof, "GET", "https://evil.example.com/api", "token-a")
+	assert.ErrorIs(t, err, passport.ErrInvalidProof)
+
+	proof, _ = passport.CreateProof(key, "GET", apiURL, "token-a")
+	_, err = proofs.Verify(ctx, proof, "GET", apiURL, "token-b")
+	assert.ErrorIs(t, err, passport.ErrInvalidProof)
+}
+
+func TestProofVerifier_Invalid(t *testing.T) {
+	requireRedis(t)
+	ctx := context.TODO()
+	proofs := passport.NewProofVerifier(rdb, passport.SetProofMaxAge(time.Minute))
+	key, _ := newClientKey(t)
+	jwk, _ := passport.NewJWK("", "", key.Public())
+
+	sign := func(header map[string]interface{}, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+		for k, v := range header {
+			token.Header[k] = v
+		}
+		s, err := token.SignedString(key)
+		assert.NoError(t, err)
+		return s
+	}
+	now := time.Now()
+	claims := func(iat time.Time) jwt.MapClaims {
+		return jwt.MapClaims{"jti": now.String(), "htm": "GET", "htu": apiURL, "iat": iat.Unix()}
+	}
+
+	// Wrong typ
+	_, err := proofs.Verify(ctx, sign(map[string]interface{}{"typ": "JWT", "jwk": jwk}, claims(now)), "GET", apiURL, "")
+	assert.ErrorIs(t, err, passport.ErrInvalidProof)
+
+	// Missing jwk
+	_, err = proofs.Verify(ctx, sign(map[string]interface{}{"typ": "dpop+jwt"}, claims(now)), "GET", apiURL, "")
+	assert.ErrorIs(t, err, passport.ErrInvalidProof)
+
+	// Expired and future proofs
+	_, err = proofs.Verify(ctx, sign(map[string]interface{}{"typ": "dpop+jwt", "jwk": jwk}, claims(now.Add(-2*time.Minute))), "GET", apiURL, "")
+	assert.ErrorIs(t, err, passport.ErrInvalidProof)
+	_, err = proofs.Verify(ctx, sign(map[string]interface{}{"typ": "dpop+jwt", "jwk": jwk}, claims(now.Add(time.Minute))), "GET", apiURL, "")
+	assert.ErrorIs(t, err, passport.ErrInvalidProof)
+
+	// Symmetric algorithms are rejected
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims(now))
+	token.Header["typ"] = "dpop+jwt"
+	token.Header["jwk"] = jwk
+	s, _ := token.SignedString([]byte(key1))
+	_, err = proofs.Verify(ctx, s, "GET", apiURL, "")
+	assert.ErrorIs(t, err, passport.ErrInvalidProof)
+}
+
+func TestAuthenticate_DPoP(t *testing.T) {
+	requireRedis(t)
+	key, jkt := newClientKey(t)
+	other, _ := newClientKey(t)
+	ts, err := x1.Create(passport.NewClaims(userId1, time.Hour).SetConfirmation(jkt))
+	assert.NoError(t, err)
+
+	claims, err := x1.Verify(ts)
+	assert.NoError(t, err)
+	assert.Equal(t, jkt, claims.Confirmation.JKT)
+
+	router := newAuthRouter(passport.NewAuthenticator(x1,
+		passport.SetExtractors(passport.FromDPoP(), passport.FromBearer()),
+		passport.SetProofVerifier(passport.NewProofVerifier(rdb, passport.SetBaseURL("https://api.example.com/"))),
+	))
+	request := func(proof string) *ut.ResponseRecorder {
+		return ut.PerformRequest(router, "GET", "/api", &ut.Body{Body: bytes.NewBuffer(nil)},
+			ut.Header{Key: "Authorization", Value: "DPoP " + ts},
+			ut.Header{Key: "DPoP", Value: proof})
+	}
+
+	proof, err := passport.CreateProof(key, "GET", apiURL, ts)
+	assert.NoError(t, err)
+	w := request(proof)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode())
+
+	// Replayed proof
+	w = request(proof)
+	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode())
+	assert.Contains(t, string(w.Result().Body()), passport.ErrProofReplayed.Error())
+
+	// Proof signed by another key
+	proof, _ = passport.CreateProof(other, "GET", apiURL, ts)
+	w = request(proof)
+	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode())
+	assert.Contains(t, string(w.Result().Body()), passport.ErrProofKeyMismatch.Error())
+
+	// Missing proof
+	w = request("")
+	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode())
+	assert.Contains(t, string(w.Result().Body()), passport.ErrMissingProof.Error())
+	assert.Equal(t, "DPoP, Bearer", string(w.Result().Header.Peek("WWW-Authenticate")))
+}
+
+func TestAuthenticate_BoundWithoutProofVerifier(t *testing.T) {
+	ts, err := x1.Create(passport.NewClaims(userId1, time.Hour).SetConfirmation("thumbprint"))
+	assert.NoError(t, err)
+	router := newAuthRouter(passport.NewAuthenticator(x1))
+
+	w := ut.PerformRequest(router, "GET", "/api", &ut.Body{Body: bytes.NewBuffer(nil)},
+		ut.Header{Key: "Authorization", Value: "Bearer " + ts})
+	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode())
+	assert.Contains(t, string(w.Result().Body()), passport.ErrMissingProof.Error())
+}
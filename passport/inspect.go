@@ -0,0 +1,206 @@
+package passport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Step is a token validation step reported by Inspect.
+type Step string
+
+// Validation steps in the order Inspect runs them.
+const (
+	StepFormat     Step = "format"
+	StepDecrypt    Step = "decrypt"
+	StepAlgorithm  Step = "algorithm"
+	StepKey        Step = "key"
+	StepSignature  Step = "signature"
+	StepExpiry     Step = "expiry"
+	StepNotBefore  Step = "not_before"
+	StepIssuedAt   Step = "issued_at"
+	StepAudience   Step = "audience"
+	StepIssuer     Step = "issuer"
+	StepClaims     Step = "required_claims"
+	StepMaxAge     Step = "max_age"
+	StepRevocation Step = "revocation"
+)
+
+// stepErrors maps validation errors to the step that produced them.
+// More specific errors come first.
+var stepErrors = []struct {
+	err  error
+	step Step
+}{
+	{ErrTokenRevoked, StepRevocation},
+	{ErrTokenExpired, StepExpiry},
+	{ErrTokenNotValidYet, StepNotBefore},
+	{ErrTokenUsedBeforeIssued, StepIssuedAt},
+	{ErrInvalidAudience, StepAudience},
+	{ErrInvalidIssuer, StepIssuer},
+	{ErrMissingClaim, StepClaims},
+	{ErrTokenTooOld, StepMaxAge},
+	{ErrInvalidJWE, StepDecrypt},
+	{ErrDecryption, StepDecrypt},
+	{ErrNotEncrypted, StepDecrypt},
+	{ErrMissingDecryptionKey, StepDecrypt},
+	{ErrUnsupportedEncryption, StepDecrypt},
+	{ErrInvalidSigningMethod, StepAlgorithm},
+	{ErrMissingKeyID, StepKey},
+	{ErrUnknownKeyID, StepKey},
+	{ErrUnknownIssuer, StepIssuer},
+	{jwt.ErrTokenSignatureInvalid, StepSignature},
+	{jwt.ErrSignatureInvalid, StepSignature},
+	{jwt.ErrTokenMalformed, StepFormat},
+}
+
+// Inspection is the result of Inspect.
+type Inspection struct {
+	// Header is the decoded JWS header, unverified.
+	Header map[string]interface{}
+	// Claims are the decoded claims, unverified.
+	Claims map[string]interface{}
+	// Valid reports whether the token passed every step.
+	Valid bool
+	// Step is the validation step that failed. It is empty if Valid, and also
+	// if verification failed for a reason other than the token, such as a
+	// Redis error of the Revoker or a failed JWKS fetch.
+	Step Step
+	// Err is the validation or server error, nil if Valid.
+	Err error
+	// Remaining is the time until the exp claim; negative if expired,
+	// zero if the token has no exp claim.
+	Remaining time.Duration
+}
+
+// Inspect decodes a token without trusting it and reports why it is or is not valid.
+// The header and claims are decoded even if verification fails, for debugging
+// and support tooling. Never use the decoded claims for authorization.
+//
+//	i := auth.Inspect(ctx, token)
+//	if !i.Valid {
+//		log.Printf("step=%s err=%v kid=%v remaining=%s", i.Step, i.Err, i.Header["kid"], i.Remaining)
+//	}
+func (x *Passport) Inspect(ctx context.Context, tokenString string) *Inspection {
+	i := &Inspection{}
+	raw := tokenString
+	if x.Encrypter != nil && isJWE(tokenString) {
+		b, err := x.Encrypter.Decrypt(tokenString)
+		if err != nil {
+			return i.fail(err)
+		}
+		raw = string(b)
+	}
+	claims := jwt.MapClaims{}
+	token, _, err := jwt.NewParser().ParseUnverified(raw, claims)
+	// An unknown alg is reported by VerifyContext with the decoded token
+	if err != nil && !unknownMethod(token, err) {
+		return i.fail(err)
+	}
+	i.Header, i.Claims = token.Header, claims
+	if exp, _ := claims.GetExpirationTime(); exp != nil {
+		i.Remaining = time.Until(exp.Time)
+	}
+	if _, err = x.VerifyContext(ctx, tokenString); err != nil {
+		return i.fail(err)
+	}
+	i.Valid = true
+	return i
+}
+
+func (i *Inspection) fail(err error) *Inspection {
+	i.Err = err
+	for _, v := range stepErrors {
+		if errors.Is(err, v.err) {
+			i.Step = v.step
+			break
+		}
+	}
+	return i
+}
+
+// Introspection is a token introspection response (RFC 7662).
+type Introspection struct {
+	Active    bool             `json:"active"`
+	Scope     string           `json:"scope,omitempty"`
+	TokenType string           `json:"token_type,omitempty"`
+	Exp       int64            `json:"exp,omitempty"`
+	Iat       int64            `json:"iat,omitempty"`
+	Nbf       int64            `json:"nbf,omitempty"`
+	Sub       string           `json:"sub,omitempty"`
+	Aud       jwt.ClaimStrings `json:"aud,omitempty"`
+	Iss       string           `json:"iss,omitempty"`
+	Jti       string           `json:"jti,omitempty"`
+	// ActiveId and Data are passport extensions.
+	ActiveId string                 `json:"active_id,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+}
+
+// Introspect verifies the token like VerifyContext and returns an RFC 7662 response.
+// Invalid tokens only report {"active": false}, and so do server errors such as
+// a Redis failure of the Revoker; IntrospectHandler answers those with 500.
+func (x *Passport) Introspect(ctx context.Context, tokenString string) Introspection {
+	v, _ := x.introspect(ctx, tokenString)
+	return v
+}
+
+func (x *Passport) introspect(ctx context.Context, tokenString string) (Introspection, error) {
+	claims, err := x.VerifyContext(ctx, tokenString)
+	if err != nil {
+		return Introspection{}, err
+	}
+	v := Introspection{
+		Active:    true,
+		Scope:     strings.Join(stringsOf(claims.Data["scope"]), " "),
+		TokenType: "Bearer",
+		Sub:       claims.Subject,
+		Aud:       claims.Audience,
+		Iss:       claims.Issuer,
+		Jti:       claims.ID,
+		ActiveId:  claims.ActiveId,
+		Data:      claims.Data,
+	}
+	if claims.Confirmation != nil {
+		v.TokenType = "DPoP"
+	}
+	if claims.ExpiresAt != nil {
+		v.Exp = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		v.Iat = claims.IssuedAt.Unix()
+	}
+	if claims.NotBefore != nil {
+		v.Nbf = claims.NotBefore.Unix()
+	}
+	return v, nil
+}
+
+// IntrospectHandler returns a Hertz handler implementing the RFC 7662 introspection
+// endpoint. The token is read from the "token" form parameter of a POST request.
+// Errors not caused by the token abort with 500 and are recorded with c.Error.
+// The endpoint must only be reachable by trusted internal services; protect it
+// with authentication middleware.
+//
+//	internal := h.Group("/internal", basicAuth)
+//	internal.POST("/introspect", auth.IntrospectHandler())
+func (x *Passport) IntrospectHandler() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		c.Header("Cache-Control", "no-store")
+		token := c.PostForm("token")
+		if token == "" {
+			c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+			return
+		}
+		v, err := x.introspect(ctx, token)
+		if err != nil && !isTokenError(err) {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.JSON(http.StatusOK, v)
+	}
+}
@@ -0,0 +1,180 @@
+package passport_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kainonly/go/passport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspect_Valid(t *testing.T) {
+	ctx := context.TODO()
+	ts, err := x1.Create(passport.NewClaims(userId1, time.Hour).SetJTI(jti1))
+	assert.NoError(t, err)
+
+	i := x1.Inspect(ctx, ts)
+	assert.True(t, i.Valid)
+	assert.Empty(t, i.Step)
+	assert.NoError(t, i.Err)
+	assert.Equal(t, "HS256", i.Header["alg"])
+	assert.Equal(t, jti1, i.Claims["jti"])
+	assert.Equal(t, userId1, i.Claims["active_id"])
+	assert.InDelta(t, time.Hour.Seconds(), i.Remaining.Seconds(), 5)
+}
+
+func TestInspect_Steps(t *testing.T) {
+	ctx := context.TODO()
+
+	i := x1.Inspect(ctx, "not-a-token")
+	assert.False(t, i.Valid)
+	assert.Equal(t, passport.StepFormat, i.Step)
+	assert.Nil(t, i.Header)
+
+	// Claims are decoded although the signature is not trusted
+	ts, _ := x2.Create(passport.NewClaims(userId1, time.Hour))
+	i = x1.Inspect(ctx, ts)
+	assert.Equal(t, passport.StepSignature, i.Step)
+	assert.Equal(t, "beta", i.Claims["iss"])
+
+	expired := passport.NewClaims(userId1, time.Hour)
+	expired.IssuedAt.Time = time.Now().Add(-2 * time.Hour)
+	expired.NotBefore.Time = expired.IssuedAt.Time
+	expired.ExpiresAt.Time = time.Now().Add(-time.Hour)
+	ts, _ = x1.Create(expired)
+	i = x1.Inspect(ctx, ts)
+	assert.Equal(t, passport.StepExpiry, i.Step)
+	assert.ErrorIs(t, i.Err, passport.ErrTokenExpired)
+	assert.Less(t, i.Remaining, time.Duration(0))
+
+	future := passport.NewClaims(userId1, time.Hour)
+	future.NotBefore.Time = time.Now().Add(time.Minute)
+	ts, _ = x1.Create(future)
+	assert.Equal(t, passport.StepNotBefore, x1.Inspect(ctx, ts).Step)
+
+	// Same key, different issuer
+	other := passport.New(passport.SetIssuer("other"), passport.SetKey(key1))
+	ts, _ = other.Create(passport.NewClaims(userId1, time.Hour))
+	assert.Equal(t, passport.StepIssuer, x1.Inspect(ctx, ts).Step)
+
+	strict := passport.New(passport.SetIssuer("dev"), passport.SetKey(key1),
+		passport.SetAudience("api"), passport.SetRequiredClaims("jti"))
+	ts, _ = x1.Create(passport.NewClaims(userId1, time.Hour).SetJTI(jti1))
+	assert.Equal(t, passport.StepAudience, strict.Inspect(ctx, ts).Step)
+	ts, _ = strict.Create(passport.NewClaims(userId1, time.Hour))
+	assert.Equal(t, passport.StepClaims, strict.Inspect(ctx, ts).Step)
+}
+
+func TestInspect_KeyAndAlgorithm(t *testing.T) {
+	ctx := context.TODO()
+
+	// HS384 is not in the allowed algorithms of x1
+	hs384 := passport.New(passport.SetIssuer("dev"), passport.SetKey(key1), passport.SetMethod(jwt.SigningMethodHS384))
+	ts, _ := hs384.Create(passport.NewClaims(userId1, time.Hour))
+	i := x1.Inspect(ctx, ts)
+	assert.Equal(t, passport.StepAlgorithm, i.Step)
+	assert.ErrorIs(t, i.Err, passport.ErrInvalidSigningMethod)
+
+	issuer := passport.New(passport.SetIssuer("dev"), passport.SetKeySet(passport.NewKeyring(passport.NewHMACKey("k2", key1))))
+	verifier := passport.New(passport.SetIssuer("dev"), passport.SetKeySet(passport.NewKeyring(passport.NewHMACKey("k1", key1))))
+	ts, _ = issuer.Create(passport.NewClaims(userId1, time.Hour))
+	i = verifier.Inspect(ctx, ts)
+	assert.Equal(t, passport.StepKey, i.Step)
+	assert.ErrorIs(t, i.Err, passport.ErrUnknownKeyID)
+
+	// Unregistered alg, the token is still decoded
+	ts = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"XYZ"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"dev"}`)) + ".c2ln"
+	i = x1.Inspect(ctx, ts)
+	assert.Equal(t, passport.StepAlgorithm, i.Step)
+	assert.ErrorIs(t, i.Err, passport.ErrInvalidSigningMethod)
+	assert.Equal(t, "XYZ", i.Header["alg"])
+	assert.Equal(t, "dev", i.Claims["iss"])
+}
+
+func TestInspect_ServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	issuer := passport.New(passport.SetIssuer("dev"), passport.SetKeySet(passport.NewKeyring(passport.NewHMACKey("k1", key1))))
+	verifier := passport.New(passport.SetIssuer("dev"), passport.SetKeySet(passport.NewRemoteKeySet(srv.URL, passport.SetMinRefresh(0))))
+	ts, _ := issuer.Create(passport.NewClaims(userId1, time.Hour))
+
+	// A failed JWKS fetch is not a validation step
+	i := verifier.Inspect(context.TODO(), ts)
+	assert.False(t, i.Valid)
+	assert.Empty(t, i.Step)
+	assert.Error(t, i.Err)
+
+	// IntrospectHandler does not report the outage as an inactive token
+	router := route.NewEngine(config.NewOptions([]config.Option{}))
+	router.POST("/introspect", verifier.IntrospectHandler())
+	form := url.Values{"token": {ts}}.Encode()
+	w := ut.PerformRequest(router, "POST", "/introspect",
+		&ut.Body{Body: bytes.NewBufferString(form), Len: len(form)},
+		ut.Header{Key: "Content-Type", Value: "application/x-www-form-urlencoded"})
+	assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode())
+	assert.False(t, verifier.Introspect(context.TODO(), ts).Active)
+}
+
+func TestInspect_Revocation(t *testing.T) {
+	requireRedis(t)
+	ctx := context.TODO()
+	r := passport.NewRevoker(rdb, passport.SetRevokePrefix("inspect-test"))
+	x := passport.New(passport.SetIssuer("dev"), passport.SetKey(key1), passport.SetRevoker(r))
+
+	claims := passport.NewClaims(userId1, time.Hour).SetJTI("inspect-jti")
+	ts, _ := x.Create(claims)
+	assert.NoError(t, r.Revoke(ctx, *claims))
+	i := x.Inspect(ctx, ts)
+	assert.Equal(t, passport.StepRevocation, i.Step)
+	assert.ErrorIs(t, i.Err, passport.ErrTokenRevoked)
+
+	rdb.Del(ctx, r.Key("jti:inspect-jti"))
+}
+
+func TestIntrospectHandler(t *testing.T) {
+	router := route.NewEngine(config.NewOptions([]config.Option{}))
+	router.POST("/introspect", x1.IntrospectHandler())
+	introspect := func(token string) (int, map[string]interface{}) {
+		form := url.Values{"token": {token}}
+		w := ut.PerformRequest(router, "POST", "/introspect",
+			&ut.Body{Body: bytes.NewBufferString(form.Encode()), Len: len(form.Encode())},
+			ut.Header{Key: "Content-Type", Value: "application/x-www-form-urlencoded"})
+		var v map[string]interface{}
+		_ = json.Unmarshal(w.Result().Body(), &v)
+		return w.Result().StatusCode(), v
+	}
+
+	ts, err := x1.Create(passport.NewClaims(userId1, time.Hour).SetJTI(jti1).
+		SetData(map[string]interface{}{"scope": []interface{}{"orders:read", "orders:write"}}))
+	assert.NoError(t, err)
+	code, v := introspect(ts)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, v["active"])
+	assert.Equal(t, "orders:read orders:write", v["scope"])
+	assert.Equal(t, "Bearer", v["token_type"])
+	assert.Equal(t, "dev", v["iss"])
+	assert.Equal(t, jti1, v["jti"])
+	assert.Equal(t, userId1, v["active_id"])
+	assert.NotZero(t, v["exp"])
+
+	code, v = introspect("invalid")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]interface{}{"active": false}, v)
+
+	code, _ = introspect("")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
@@ -0,0 +1,149 @@
+package passport
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrUnknownIssuer is returned when no Passport is configured for the token issuer.
+var ErrUnknownIssuer = errors.New("passport: unknown token issuer")
+
+// Issuers verifies tokens from several issuers. It routes each token on its
+// iss claim to the Passport configured for that issuer, so every issuer has
+// its own keys, allowed algorithms, audience and other validation options.
+//
+//	issuers := passport.NewIssuers(
+//		auth, // our own tokens
+//		passport.New(
+//			passport.SetIssuer("https://partner-a.example.com"),
+//			passport.SetKeySet(passport.NewRemoteKeySet("https://partner-a.example.com/jwks.json")),
+//			passport.SetAudience("our-api"),
+//		),
+//		passport.New(
+//			passport.SetIssuer("partner-b"),
+//			passport.SetPublicKeys(partnerBKey),
+//			passport.SetAlgorithms("RS256"),
+//		),
+//	)
+//	authn := passport.NewAuthenticator(issuers)
+//	api.GET("/profile", func(ctx context.Context, c *app.RequestContext) {
+//		issuer := passport.GetIssuer(c)
+//		...
+//	})
+type Issuers struct {
+	mu        sync.RWMutex
+	passports map[string]*Passport
+}
+
+// NewIssuers creates Issuers with the given passports, keyed by their Issuer.
+func NewIssuers(passports ...*Passport) *Issuers {
+	x := &Issuers{passports: make(map[string]*Passport)}
+	x.Add(passports...)
+	return x
+}
+
+// Add adds passports, replacing any passport with the same Issuer.
+func (x *Issuers) Add(passports ...*Passport) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, v := range passports {
+		x.passports[v.Issuer] = v
+	}
+}
+
+// Remove removes the passport of the given issuer.
+func (x *Issuers) Remove(issuer string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.passports, issuer)
+}
+
+// Get returns the passport of the given issuer.
+func (x *Issuers) Get(issuer string) (*Passport, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	p, ok := x.passports[issuer]
+	return p, ok
+}
+
+// Issuers returns the configured issuers, sorted.
+func (x *Issuers) Issuers() []string {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	issuers := make([]string, 0, len(x.passports))
+	for k := range x.passports {
+		issuers = append(issuers, k)
+	}
+	sort.Strings(issuers)
+	return issuers
+}
+
+// Route returns the passport responsible for the token, by its unverified iss claim.
+// Encrypted tokens are routed to the first passport able to decrypt them.
+// Returns ErrUnknownIssuer if no passport is configured for the issuer.
+func (x *Issuers) Route(tokenString string) (*Passport, error) {
+	raw := tokenString
+	if isJWE(tokenString) {
+		raw = ""
+		x.mu.RLock()
+		for _, p := range x.passports {
+			if p.Encrypter == nil {
+				continue
+			}
+			if b, err := p.Encrypter.Decrypt(tokenString); err == nil {
+				raw = string(b)
+				break
+			}
+		}
+		x.mu.RUnlock()
+		if raw == "" {
+			return nil, ErrUnknownIssuer
+		}
+	}
+	var rc jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(raw, &rc); err != nil {
+		return nil, err
+	}
+	p, ok := x.Get(rc.Issuer)
+	if !ok {
+		return nil, ErrUnknownIssuer
+	}
+	return p, nil
+}
+
+// Verify routes the token to its issuer and verifies it with that Passport.
+// Returns the claims and the issuer that authenticated the token.
+func (x *Issuers) Verify(tokenString string) (Claims, string, error) {
+	p, err := x.Route(tokenString)
+	if err != nil {
+		return Claims{}, "", err
+	}
+	claims, err := p.Verify(tokenString)
+	if err != nil {
+		return claims, "", err
+	}
+	return claims, p.Issuer, nil
+}
+
+// VerifyContext verifies the token like Verify and additionally checks the
+// Revoker of the issuer's Passport. Issuers implements Verifier; the issuer
+// is available as Claims.Issuer.
+func (x *Issuers) VerifyContext(ctx context.Context, tokenString string) (Claims, error) {
+	p, err := x.Route(tokenString)
+	if err != nil {
+		return Claims{}, err
+	}
+	return p.VerifyContext(ctx, tokenString)
+}
+
+// GetIssuer returns the issuer of the Claims stored by Authenticate,
+// or an empty string if the request is not authenticated.
+func GetIssuer(c *app.RequestContext) string {
+	claims, _ := GetClaims(c)
+	return claims.Issuer
+}
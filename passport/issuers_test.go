@@ -0,0 +1,108 @@
+package passport_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kainonly/go/passport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssuers(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	partner := passport.New(
+		passport.SetIssuer("partner"),
+		passport.SetMethod(jwt.SigningMethodES256),
+		passport.SetPrivateKey(ecKey),
+		passport.SetAudience("api"),
+	)
+	// Verification-only view of the partner issuer
+	partnerPublic := passport.New(
+		passport.SetIssuer("partner"),
+		passport.SetPublicKeys(ecKey.Public()),
+		passport.SetAudience("api"),
+	)
+	issuers := passport.NewIssuers(x1, x2, partnerPublic)
+	assert.Equal(t, []string{"beta", "dev", "partner"}, issuers.Issuers())
+
+	ts1, _ := x1.Create(passport.NewClaims(userId1, time.Hour))
+	claims, issuer, err := issuers.Verify(ts1)
+	assert.NoError(t, err)
+	assert.Equal(t, "dev", issuer)
+	assert.Equal(t, userId1, claims.ActiveId)
+
+	ts2, _ := x2.Create(passport.NewClaims(userId2, time.Hour))
+	_, issuer, err = issuers.Verify(ts2)
+	assert.NoError(t, err)
+	assert.Equal(t, "beta", issuer)
+
+	ts3, err := partner.Create(passport.NewClaims(userId1, time.Hour))
+	assert.NoError(t, err)
+	_, issuer, err = issuers.Verify(ts3)
+	assert.NoError(t, err)
+	assert.Equal(t, "partner", issuer)
+
+	// Per-issuer options apply: "partner" requires the audience
+	claims3 := passport.NewClaims(userId1, time.Hour)
+	claims3.Issuer = "partner"
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodES256, claims3).SignedString(ecKey)
+	_, _, err = issuers.Verify(forged)
+	assert.ErrorIs(t, err, passport.ErrInvalidAudience)
+
+	// A token claiming another issuer is checked against that issuer's key
+	spoofed := passport.New(passport.SetIssuer("dev"), passport.SetKey("eK4qpn7yCBLo0u5mlAFFRCRsCmf2NQ76"))
+	ts, _ := spoofed.Create(passport.NewClaims(userId1, time.Hour))
+	_, _, err = issuers.Verify(ts)
+	assert.ErrorIs(t, err, jwt.ErrSignatureInvalid)
+
+	unknown := passport.New(passport.SetIssuer("unknown"), passport.SetKey(key1))
+	ts, _ = unknown.Create(passport.NewClaims(userId1, time.Hour))
+	_, _, err = issuers.Verify(ts)
+	assert.ErrorIs(t, err, passport.ErrUnknownIssuer)
+
+	issuers.Remove("beta")
+	_, _, err = issuers.Verify(ts2)
+	assert.ErrorIs(t, err, passport.ErrUnknownIssuer)
+}
+
+func TestIssuers_Encrypted(t *testing.T) {
+	enc, err := passport.NewEncrypter(bytes.Repeat([]byte{1}, 32))
+	assert.NoError(t, err)
+	secret := passport.New(passport.SetIssuer("secret"), passport.SetKey(key1), passport.SetEncrypter(enc))
+	issuers := passport.NewIssuers(x1, secret)
+
+	ts, err := secret.Create(passport.NewClaims(userId1, time.Hour))
+	assert.NoError(t, err)
+	_, issuer, err := issuers.Verify(ts)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", issuer)
+}
+
+func TestIssuers_Authenticate(t *testing.T) {
+	authn := passport.NewAuthenticator(passport.NewIssuers(x1, x2))
+	router := route.NewEngine(config.NewOptions([]config.Option{}))
+	router.GET("/api", authn.Authenticate(), func(ctx context.Context, c *app.RequestContext) {
+		c.JSON(http.StatusOK, map[string]string{"issuer": passport.GetIssuer(c)})
+	})
+
+	ts, _ := x2.Create(passport.NewClaims(userId2, time.Hour))
+	w := ut.PerformRequest(router, "GET", "/api", nil,
+		ut.Header{Key: "Authorization", Value: "Bearer " + ts})
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode())
+	var v map[string]string
+	assert.NoError(t, json.Unmarshal(w.Result().Body(), &v))
+	assert.Equal(t, "beta", v["issuer"])
+}
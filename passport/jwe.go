@@ -0,0 +1,340 @@
+package passport
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Errors returned by token encryption.
+var (
+	ErrInvalidJWE            = errors.New("passport: invalid encrypted token")
+	ErrDecryption            = errors.New("passport: token decryption failed")
+	ErrNotEncrypted          = errors.New("passport: token is not encrypted")
+	ErrMissingDecryptionKey  = errors.New("passport: no decryption key configured")
+	ErrUnsupportedEncryption = errors.New("passport: unsupported encryption algorithm")
+)
+
+// Key management algorithms supported by Encrypter.
+const (
+	AlgDir        = "dir"
+	AlgRSAOAEP256 = "RSA-OAEP-256"
+	AlgECDHES     = "ECDH-ES"
+)
+
+// Encrypter produces and opens compact JWE tokens (RFC 7516) with AES-GCM
+// content encryption. The signed JWT is nested inside the JWE, so claims are
+// both authenticated by the signature and unreadable without the key.
+//
+// The key management algorithm is chosen by the key type:
+//   - []byte of 16, 24 or 32 bytes: "dir" with A128GCM, A192GCM or A256GCM
+//   - *rsa.PrivateKey or *rsa.PublicKey: "RSA-OAEP-256" with A256GCM
+//   - *ecdh.PrivateKey, *ecdh.PublicKey, *ecdsa.PrivateKey or *ecdsa.PublicKey: "ECDH-ES" with A256GCM
+//
+// Public keys can only encrypt; Verify needs the private key.
+type Encrypter struct {
+	// Algorithm is the key management algorithm ("alg" header).
+	Algorithm string
+	// Encryption is the content encryption algorithm ("enc" header).
+	Encryption string
+
+	secret []byte
+	rsaPub *rsa.PublicKey
+	rsaKey *rsa.PrivateKey
+	ecPub  *ecdh.PublicKey
+	ecKey  *ecdh.PrivateKey
+}
+
+// NewEncrypter creates an Encrypter for the given key.
+// Returns ErrUnsupportedKey for unsupported key types or lengths.
+func NewEncrypter(key interface{}) (*Encrypter, error) {
+	x := &Encrypter{Encryption: "A256GCM"}
+	switch k := key.(type) {
+	case []byte:
+		switch len(k) {
+		case 16:
+			x.Encryption = "A128GCM"
+		case 24:
+			x.Encryption = "A192GCM"
+		case 32:
+		default:
+			return nil, ErrUnsupportedKey
+		}
+		x.Algorithm = AlgDir
+		x.secret = k
+	case *rsa.PrivateKey:
+		x.Algorithm = AlgRSAOAEP256
+		x.rsaKey, x.rsaPub = k, &k.PublicKey
+	case *rsa.PublicKey:
+		x.Algorithm = AlgRSAOAEP256
+		x.rsaPub = k
+	case *ecdsa.PrivateKey:
+		ek, err := k.ECDH()
+		if err != nil {
+			return nil, err
+		}
+		return NewEncrypter(ek)
+	case *ecdsa.PublicKey:
+		ek, err := k.ECDH()
+		if err != nil {
+			return nil, err
+		}
+		return NewEncrypter(ek)
+	case *ecdh.PrivateKey:
+		x.Algorithm = AlgECDHES
+		x.ecKey, x.ecPub = k, k.PublicKey()
+	case *ecdh.PublicKey:
+		x.Algorithm = AlgECDHES
+		x.ecPub = k
+	default:
+		return nil, ErrUnsupportedKey
+	}
+	return x, nil
+}
+
+// jweHeader is the protected header of a JWE.
+type jweHeader struct {
+	Alg string `json:"alg"`
+	Enc string `json:"enc"`
+	Cty string `json:"cty,omitempty"`
+	Epk *JWK   `json:"epk,omitempty"`
+	Zip string `json:"zip,omitempty"`
+}
+
+// isJWE reports whether the token is in JWE compact serialization (five parts).
+func isJWE(token string) bool {
+	return strings.Count(token, ".") == 4
+}
+
+// Encrypt encrypts the plaintext into a compact JWE.
+// cty is written to the header, "JWT" for nested tokens.
+func (x *Encrypter) Encrypt(plaintext []byte, cty string) (string, error) {
+	header := jweHeader{Alg: x.Algorithm, Enc: x.Encryption, Cty: cty}
+	var cek, encryptedKey []byte
+	var err error
+	switch x.Algorithm {
+	case AlgDir:
+		cek = x.secret
+	case AlgRSAOAEP256:
+		cek = make([]byte, 32)
+		if _, err = rand.Read(cek); err != nil {
+			return "", err
+		}
+		if encryptedKey, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, x.rsaPub, cek, nil); err != nil {
+			return "", err
+		}
+	case AlgECDHES:
+		ephemeral, err := x.ecPub.Curve().GenerateKey(rand.Reader)
+		if err != nil {
+			return "", err
+		}
+		if header.Epk, err = ecdhJWK(ephemeral.PublicKey()); err != nil {
+			return "", err
+		}
+		if cek, err = x.agree(ephemeral, x.ecPub); err != nil {
+			return "", err
+		}
+	default:
+		return "", ErrUnsupportedEncryption
+	}
+
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	protected := b64.EncodeToString(h)
+	aead, err := newGCM(cek)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(iv); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nil, iv, plaintext, []byte(protected))
+	ciphertext, tag := sealed[:len(sealed)-aead.Overhead()], sealed[len(sealed)-aead.Overhead():]
+	return strings.Join([]string{
+		protected,
+		b64.EncodeToString(encryptedKey),
+		b64.EncodeToString(iv),
+		b64.EncodeToString(ciphertext),
+		b64.EncodeToString(tag),
+	}, "."), nil
+}
+
+// Decrypt opens a compact JWE and returns the plaintext.
+// The "alg" and "enc" headers must match the Encrypter.
+func (x *Encrypter) Decrypt(token string) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		return nil, ErrInvalidJWE
+	}
+	raw := make([][]byte, 5)
+	for i, v := range parts {
+		b, err := b64.DecodeString(v)
+		if err != nil {
+			return nil, ErrInvalidJWE
+		}
+		raw[i] = b
+	}
+	var header jweHeader
+	if err := json.Unmarshal(raw[0], &header); err != nil {
+		return nil, ErrInvalidJWE
+	}
+	if header.Alg != x.Algorithm || header.Enc != x.Encryption || header.Zip != "" {
+		return nil, ErrUnsupportedEncryption
+	}
+
+	var cek []byte
+	var err error
+	switch x.Algorithm {
+	case AlgDir:
+		if len(raw[1]) != 0 {
+			return nil, ErrInvalidJWE
+		}
+		cek = x.secret
+	case AlgRSAOAEP256:
+		if x.rsaKey == nil {
+			return nil, ErrMissingDecryptionKey
+		}
+		if cek, err = rsa.DecryptOAEP(sha256.New(), nil, x.rsaKey, raw[1], nil); err != nil {
+			return nil, ErrDecryption
+		}
+	case AlgECDHES:
+		if x.ecKey == nil {
+			return nil, ErrMissingDecryptionKey
+		}
+		if header.Epk == nil {
+			return nil, ErrInvalidJWE
+		}
+		epk, err := header.Epk.ecdhPublicKey(x.ecKey.Curve())
+		if err != nil {
+			return nil, ErrInvalidJWE
+		}
+		if cek, err = x.agree(x.ecKey, epk); err != nil {
+			return nil, ErrDecryption
+		}
+	}
+
+	// An RSA-OAEP key of another size would silently downgrade the cipher
+	if len(cek) != encKeySizes[x.Encryption] {
+		return nil, ErrDecryption
+	}
+	aead, err := newGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw[2]) != aead.NonceSize() || len(raw[4]) != aead.Overhead() {
+		return nil, ErrInvalidJWE
+	}
+	plaintext, err := aead.Open(nil, raw[2], append(raw[3], raw[4]...), []byte(parts[0]))
+	if err != nil {
+		return nil, ErrDecryption
+	}
+	return plaintext, nil
+}
+
+// agree derives the content encryption key for ECDH-ES (RFC 7518 section 4.6)
+// using the Concat KDF with SHA-256 and empty PartyUInfo/PartyVInfo.
+func (x *Encrypter) agree(priv *ecdh.PrivateKey, pub *ecdh.PublicKey) ([]byte, error) {
+	z, err := priv.ECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+	keyLen := 32
+	h := sha256.New()
+	_ = binary.Write(h, binary.BigEndian, uint32(1))
+	h.Write(z)
+	_ = binary.Write(h, binary.BigEndian, uint32(len(x.Encryption)))
+	h.Write([]byte(x.Encryption))
+	_ = binary.Write(h, binary.BigEndian, uint32(0)) // PartyUInfo
+	_ = binary.Write(h, binary.BigEndian, uint32(0)) // PartyVInfo
+	_ = binary.Write(h, binary.BigEndian, uint32(keyLen*8))
+	return h.Sum(nil)[:keyLen], nil
+}
+
+// encKeySizes are the content encryption key sizes of the "enc" algorithms.
+var encKeySizes = map[string]int{
+	"A128GCM": 16,
+	"A192GCM": 24,
+	"A256GCM": 32,
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ecdhJWK encodes an ECDH public key as the "epk" JWK.
+func ecdhJWK(key *ecdh.PublicKey) (*JWK, error) {
+	if key.Curve() == ecdh.X25519() {
+		return &JWK{Kty: "OKP", Crv: "X25519", X: b64.EncodeToString(key.Bytes())}, nil
+	}
+	pub, err := ecdsaFromECDH(key)
+	if err != nil {
+		return nil, err
+	}
+	jwk, err := NewJWK("", "", pub)
+	if err != nil {
+		return nil, err
+	}
+	jwk.Use, jwk.Alg = "", ""
+	return &jwk, nil
+}
+
+// ecdhPublicKey decodes the JWK as an ECDH public key on the given curve.
+func (x JWK) ecdhPublicKey(curve ecdh.Curve) (*ecdh.PublicKey, error) {
+	if curve == ecdh.X25519() {
+		if x.Kty != "OKP" || x.Crv != "X25519" {
+			return nil, ErrInvalidJWK
+		}
+		b, err := b64.DecodeString(x.X)
+		if err != nil {
+			return nil, err
+		}
+		return curve.NewPublicKey(b)
+	}
+	pub, err := x.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	k, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, ErrInvalidJWK
+	}
+	ek, err := k.ECDH()
+	if err != nil {
+		return nil, err
+	}
+	if ek.Curve() != curve {
+		return nil, ErrInvalidJWK
+	}
+	return ek, nil
+}
+
+func ecdsaFromECDH(key *ecdh.PublicKey) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch key.Curve() {
+	case ecdh.P256():
+		curve = elliptic.P256()
+	case ecdh.P384():
+		curve = elliptic.P384()
+	case ecdh.P521():
+		curve = elliptic.P521()
+	default:
+		return nil, ErrUnsupportedKey
+	}
+	return ecdsa.ParseUncompressedPublicKey(curve, key.Bytes())
+}
@@ -0,0 +1,134 @@
+package passport_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kainonly/go/passport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncrypter(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	xKey, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	cases := []struct {
+		key interface{}
+		alg string
+		enc string
+	}{
+		{[]byte("0123456789abcdef"), "dir", "A128GCM"},
+		{[]byte("0123456789abcdef0123456789abcdef"), "dir", "A256GCM"},
+		{rsaKey, "RSA-OAEP-256", "A256GCM"},
+		{ecKey, "ECDH-ES", "A256GCM"},
+		{xKey, "ECDH-ES", "A256GCM"},
+	}
+	for _, v := range cases {
+		enc, err := passport.NewEncrypter(v.key)
+		assert.NoError(t, err)
+		assert.Equal(t, v.alg, enc.Algorithm)
+		assert.Equal(t, v.enc, enc.Encryption)
+
+		x := passport.New(passport.SetIssuer("dev"), passport.SetKey(key1), passport.SetEncrypter(enc))
+		ts, err := x.Create(passport.NewClaims(userId1, time.Hour).SetData(map[string]interface{}{
+			"tenant": "secret-tenant",
+		}))
+		assert.NoError(t, err)
+		parts := strings.Split(ts, ".")
+		assert.Len(t, parts, 5)
+
+		header, err := base64.RawURLEncoding.DecodeString(parts[0])
+		assert.NoError(t, err)
+		var h map[string]interface{}
+		assert.NoError(t, json.Unmarshal(header, &h))
+		assert.Equal(t, v.alg, h["alg"])
+		assert.Equal(t, "JWT", h["cty"])
+		// Payload is not readable
+		assert.NotContains(t, ts, base64.RawURLEncoding.EncodeToString([]byte("secret-tenant")))
+
+		claims, err := x.Verify(ts)
+		assert.NoError(t, err)
+		assert.Equal(t, "secret-tenant", claims.Data["tenant"])
+
+		// Plain JWS tokens are rejected
+		plain, err := x1.Create(passport.NewClaims(userId1, time.Hour))
+		assert.NoError(t, err)
+		_, err = x.Verify(plain)
+		assert.ErrorIs(t, err, passport.ErrNotEncrypted)
+
+		// Tampering is detected
+		parts[3] = base64.RawURLEncoding.EncodeToString([]byte("tampered"))
+		_, err = x.Verify(strings.Join(parts, "."))
+		assert.Error(t, err)
+	}
+}
+
+func TestEncrypter_PublicKeyOnly(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	sender, err := passport.NewEncrypter(&rsaKey.PublicKey)
+	assert.NoError(t, err)
+	receiver, err := passport.NewEncrypter(rsaKey)
+	assert.NoError(t, err)
+
+	ts, err := sender.Encrypt([]byte("hello"), "")
+	assert.NoError(t, err)
+	_, err = sender.Decrypt(ts)
+	assert.ErrorIs(t, err, passport.ErrMissingDecryptionKey)
+	plaintext, err := receiver.Decrypt(ts)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(plaintext))
+}
+
+func TestEncrypter_Mismatch(t *testing.T) {
+	a, _ := passport.NewEncrypter([]byte("0123456789abcdef0123456789abcdef"))
+	b, _ := passport.NewEncrypter([]byte("fedcba9876543210fedcba9876543210"))
+	c, _ := passport.NewEncrypter([]byte("0123456789abcdef"))
+	ts, err := a.Encrypt([]byte("hello"), "")
+	assert.NoError(t, err)
+	_, err = b.Decrypt(ts)
+	assert.ErrorIs(t, err, passport.ErrDecryption)
+	_, err = c.Decrypt(ts)
+	assert.ErrorIs(t, err, passport.ErrUnsupportedEncryption)
+	_, err = a.Decrypt("a.b.c")
+	assert.ErrorIs(t, err, passport.ErrInvalidJWE)
+
+	_, err = passport.NewEncrypter([]byte("short"))
+	assert.ErrorIs(t, err, passport.ErrUnsupportedKey)
+	_, err = passport.NewEncrypter("string")
+	assert.ErrorIs(t, err, passport.ErrUnsupportedKey)
+}
+
+func TestEncrypter_CEKSize(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	x, err := passport.NewEncrypter(rsaKey)
+	assert.NoError(t, err)
+
+	// A 16-byte key under "A256GCM" must not be accepted as AES-128
+	b64 := base64.RawURLEncoding
+	header := b64.EncodeToString([]byte(`{"alg":"RSA-OAEP-256","enc":"A256GCM"}`))
+	cek := make([]byte, 16)
+	_, _ = rand.Read(cek)
+	ek, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, &rsaKey.PublicKey, cek, nil)
+	assert.NoError(t, err)
+	block, _ := aes.NewCipher(cek)
+	aead, _ := cipher.NewGCM(block)
+	iv := make([]byte, aead.NonceSize())
+	sealed := aead.Seal(nil, iv, []byte("hello"), []byte(header))
+	ct, tag := sealed[:len(sealed)-aead.Overhead()], sealed[len(sealed)-aead.Overhead():]
+	ts := strings.Join([]string{header, b64.EncodeToString(ek), b64.EncodeToString(iv),
+		b64.EncodeToString(ct), b64.EncodeToString(tag)}, ".")
+
+	_, err = x.Decrypt(ts)
+	assert.ErrorIs(t, err, passport.ErrDecryption)
+}
@@ -0,0 +1,415 @@
+package passport
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Errors returned by JWKS functions.
+var (
+	ErrUnsupportedKey = errors.New("passport: unsupported key type")
+	ErrInvalidJWK     = errors.New("passport: invalid jwk")
+)
+
+// JWK is a JSON Web Key (RFC 7517) holding a public key.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set document.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// NewJWK encodes a public key as a JWK.
+// Supported key types are *rsa.PublicKey, *ecdsa.PublicKey (NIST or SM2 curve)
+// and ed25519.PublicKey.
+func NewJWK(kid string, alg string, key crypto.PublicKey) (JWK, error) {
+	x := JWK{Kid: kid, Use: "sig", Alg: alg}
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		x.Kty = "RSA"
+		x.N = b64.EncodeToString(k.N.Bytes())
+		x.E = b64.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+	case *ecdsa.PublicKey:
+		if isSM2(k) {
+			sm2JWK(&x, k)
+			break
+		}
+		point, err := k.Bytes()
+		if err != nil {
+			return x, err
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		x.Kty = "EC"
+		x.Crv = k.Curve.Params().Name
+		x.X = b64.EncodeToString(point[1 : 1+size])
+		x.Y = b64.EncodeToString(point[1+size:])
+	case ed25519.PublicKey:
+		x.Kty = "OKP"
+		x.Crv = "Ed25519"
+		x.X = b64.EncodeToString(k)
+	default:
+		return x, ErrUnsupportedKey
+	}
+	return x, nil
+}
+
+// PublicKey decodes the JWK into a public key.
+func (x JWK) PublicKey() (crypto.PublicKey, error) {
+	switch x.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(x.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64.DecodeString(x.E)
+		if err != nil {
+			return nil, err
+		}
+		if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, ErrInvalidJWK
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch x.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		case "SM2":
+			return x.sm2PublicKey()
+		default:
+			return nil, ErrUnsupportedKey
+		}
+		px, err := b64.DecodeString(x.X)
+		if err != nil {
+			return nil, err
+		}
+		py, err := b64.DecodeString(x.Y)
+		if err != nil {
+			return nil, err
+		}
+		point := append(append([]byte{4}, px...), py...)
+		key, err := ecdsa.ParseUncompressedPublicKey(curve, point)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidJWK, err)
+		}
+		return key, nil
+	case "OKP":
+		if x.Crv != "Ed25519" {
+			return nil, ErrUnsupportedKey
+		}
+		px, err := b64.DecodeString(x.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(px) != ed25519.PublicKeySize {
+			return nil, ErrInvalidJWK
+		}
+		return ed25519.PublicKey(px), nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+// Thumbprint computes the RFC 7638 JWK thumbprint (base64url SHA-256).
+func (x JWK) Thumbprint() string {
+	// Required members only, in lexicographic order
+	var v string
+	switch x.Kty {
+	case "RSA":
+		v = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, x.E, x.N)
+	case "EC":
+		v = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, x.Crv, x.X, x.Y)
+	default:
+		v = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q}`, x.Crv, x.Kty, x.X)
+	}
+	sum := sha256.Sum256([]byte(v))
+	return b64.EncodeToString(sum[:])
+}
+
+// thumbprint returns the RFC 7638 thumbprint of a public key.
+func thumbprint(key crypto.PublicKey) (string, error) {
+	jwk, err := NewJWK("", "", key)
+	if err != nil {
+		return "", err
+	}
+	return jwk.Thumbprint(), nil
+}
+
+// JWKS exports the public verification keys as a JWKS document.
+// HMAC secrets are never exported. Keys without a kid (PublicKeys)
+// are published under their RFC 7638 thumbprint, which Create also
+// writes to the token header, with the alg of Method if it fits the key.
+func (x *Passport) JWKS() (*JWKS, error) {
+	var keys []*SigningKey
+	if x.KeySet != nil {
+		ks, ok := x.KeySet.(interface{ Keys() []*SigningKey })
+		if ok {
+			keys = ks.Keys()
+		}
+	} else {
+		for _, v := range x.PublicKeys {
+			kid, err := thumbprint(v)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, &SigningKey{ID: kid, Method: methodFor(x.method(), v), Public: v})
+		}
+	}
+	set := &JWKS{Keys: []JWK{}}
+	for _, v := range keys {
+		if _, ok := v.Method.(*jwt.SigningMethodHMAC); ok {
+			continue
+		}
+		jwk, err := NewJWK(v.ID, v.Method.Alg(), v.Public)
+		if err != nil {
+			if errors.Is(err, ErrUnsupportedKey) {
+				continue
+			}
+			return nil, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// JWKSHandler returns a Hertz handler serving the JWKS document.
+//
+//	h.GET("/.well-known/jwks.json", auth.JWKSHandler())
+func (x *Passport) JWKSHandler() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		set, err := x.JWKS()
+		if err != nil {
+			c.Error(err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, set)
+	}
+}
+
+// RemoteKeySet is a KeySet loaded from a JWKS endpoint.
+// Keys are cached for CacheTTL and refreshed when they expire or when an
+// unknown kid is seen (at most once per MinRefresh).
+//
+//	keys := passport.NewRemoteKeySet("https://auth.example.com/.well-known/jwks.json")
+//	verifier := passport.New(passport.SetIssuer("auth"), passport.SetKeySet(keys))
+type RemoteKeySet struct {
+	// URL is the JWKS endpoint.
+	URL string
+	// Client is the HTTP client used for fetching (default: 10s timeout).
+	Client *http.Client
+	// CacheTTL is how long fetched keys are trusted (default: 5 minutes).
+	CacheTTL time.Duration
+	// MinRefresh is the minimum interval between fetches (default: 10 seconds).
+	MinRefresh time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]*SigningKey
+	fetchedAt time.Time
+	// fetching is closed when the running fetch completes, nil if none runs.
+	fetching chan struct{}
+	fetchErr error
+}
+
+// NewRemoteKeySet creates a RemoteKeySet for the given JWKS URL.
+func NewRemoteKeySet(url string, options ...RemoteOption) *RemoteKeySet {
+	x := &RemoteKeySet{
+		URL:        url,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+		CacheTTL:   5 * time.Minute,
+		MinRefresh: 10 * time.Second,
+	}
+	for _, v := range options {
+		v(x)
+	}
+	return x
+}
+
+// RemoteOption is a function that configures a RemoteKeySet instance.
+type RemoteOption func(x *RemoteKeySet)
+
+// SetHTTPClient sets the HTTP client used to fetch the JWKS.
+func SetHTTPClient(v *http.Client) RemoteOption {
+	return func(x *RemoteKeySet) {
+		x.Client = v
+	}
+}
+
+// SetCacheTTL sets how long fetched keys are cached.
+func SetCacheTTL(v time.Duration) RemoteOption {
+	return func(x *RemoteKeySet) {
+		x.CacheTTL = v
+	}
+}
+
+// SetMinRefresh sets the minimum interval between JWKS fetches.
+func SetMinRefresh(v time.Duration) RemoteOption {
+	return func(x *RemoteKeySet) {
+		x.MinRefresh = v
+	}
+}
+
+// Lookup returns the key with the given kid, fetching the JWKS if needed.
+// It behaves like LookupContext without cancellation.
+func (x *RemoteKeySet) Lookup(kid string) (*SigningKey, error) {
+	return x.LookupContext(context.Background(), kid)
+}
+
+// LookupContext returns the key with the given kid, fetching the JWKS if needed.
+// Fetches run in the background, one at a time: expired keys keep being served
+// while a refresh runs or after it fails, and only lookups of an unknown kid
+// wait for the fetch, until ctx is done.
+func (x *RemoteKeySet) LookupContext(ctx context.Context, kid string) (*SigningKey, error) {
+	x.mu.Lock()
+	key, ok := x.keys[kid]
+	since := time.Since(x.fetchedAt)
+	if ok && since < x.CacheTTL {
+		x.mu.Unlock()
+		return key, nil
+	}
+	if since < x.MinRefresh && x.fetching == nil {
+		err := x.fetchErr
+		x.mu.Unlock()
+		if ok {
+			return key, nil
+		}
+		// The JWKS is unavailable, not missing the key
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrUnknownKeyID
+	}
+	done := x.fetch()
+	x.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if key, ok = x.keys[kid]; ok {
+		return key, nil
+	}
+	if x.fetchErr != nil {
+		return nil, x.fetchErr
+	}
+	return nil, ErrUnknownKeyID
+}
+
+// fetch starts a background fetch unless one is running and returns a channel
+// closed when it completes. x.mu must be held.
+func (x *RemoteKeySet) fetch() <-chan struct{} {
+	if x.fetching != nil {
+		return x.fetching
+	}
+	done := make(chan struct{})
+	x.fetching = done
+	x.fetchedAt = time.Now()
+	go func() {
+		// Shared by all waiting lookups, bounded by the client timeout
+		keys, err := x.load(context.Background())
+		x.mu.Lock()
+		if err == nil {
+			x.keys = keys
+		}
+		x.fetchErr = err
+		x.fetching = nil
+		x.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+// Refresh fetches the JWKS immediately.
+func (x *RemoteKeySet) Refresh(ctx context.Context) error {
+	keys, err := x.load(ctx)
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.fetchedAt, x.fetchErr = time.Now(), err
+	if err != nil {
+		return err
+	}
+	x.keys = keys
+	return nil
+}
+
+// load fetches and decodes the JWKS; keys with another use or an unknown alg are skipped.
+func (x *RemoteKeySet) load(ctx context.Context) (map[string]*SigningKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, x.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := x.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("passport: jwks fetch failed with status %d", resp.StatusCode)
+	}
+	var set JWKS
+	if err = json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*SigningKey, len(set.Keys))
+	for _, v := range set.Keys {
+		if v.Use != "" && v.Use != "sig" {
+			continue
+		}
+		pub, err := v.PublicKey()
+		if err != nil {
+			continue
+		}
+		key := NewPublicKey(v.Kid, pub)
+		if v.Alg != "" {
+			method := jwt.GetSigningMethod(v.Alg)
+			if method == nil {
+				continue
+			}
+			key.SetMethod(method)
+		}
+		keys[v.Kid] = key
+	}
+	return keys, nil
+}
@@ -0,0 +1,239 @@
+package passport_test
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kainonly/go/passport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWK_RoundTrip(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	for _, pub := range []crypto.PublicKey{&rsaKey.PublicKey, ecKey.Public(), edPub} {
+		jwk, err := passport.NewJWK("k", "", pub)
+		assert.NoError(t, err)
+		decoded, err := jwk.PublicKey()
+		assert.NoError(t, err)
+		assert.True(t, decoded.(interface{ Equal(crypto.PublicKey) bool }).Equal(pub))
+		assert.NotEmpty(t, jwk.Thumbprint())
+	}
+
+	_, err := passport.NewJWK("k", "", []byte("secret"))
+	assert.ErrorIs(t, err, passport.ErrUnsupportedKey)
+}
+
+func TestJWK_Thumbprint(t *testing.T) {
+	// RFC 7638 section 3.1 example
+	jwk := passport.JWK{
+		Kty: "RSA",
+		N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECP" +
+			"ebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQ" +
+			"MicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRw" +
+			"r3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E: "AQAB",
+	}
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", jwk.Thumbprint())
+}
+
+func TestJWKS_Export(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ring := passport.NewKeyring(
+		passport.NewHMACKey("hmac", key1),
+		passport.NewPrivateKey("ec", ecKey),
+	)
+	x := passport.New(passport.SetIssuer("dev"), passport.SetKeySet(ring))
+	set, err := x.JWKS()
+	assert.NoError(t, err)
+	// HMAC secrets are never exported
+	assert.Len(t, set.Keys, 1)
+	assert.Equal(t, "ec", set.Keys[0].Kid)
+	assert.Equal(t, "ES256", set.Keys[0].Alg)
+	assert.Equal(t, "EC", set.Keys[0].Kty)
+
+	set, err = x1.JWKS()
+	assert.NoError(t, err)
+	assert.Empty(t, set.Keys)
+}
+
+func TestJWKSHandler(t *testing.T) {
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	x := passport.New(passport.SetIssuer("dev"), passport.SetPrivateKey(edKey))
+	router := route.NewEngine(config.NewOptions([]config.Option{}))
+	router.GET("/.well-known/jwks.json", x.JWKSHandler())
+	w := ut.PerformRequest(router, "GET", "/.well-known/jwks.json", &ut.Body{Body: bytes.NewBuffer(nil)})
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+
+	var set passport.JWKS
+	assert.NoError(t, json.Unmarshal(resp.Body(), &set))
+	assert.Len(t, set.Keys, 1)
+	assert.Equal(t, "OKP", set.Keys[0].Kty)
+	assert.Equal(t, "EdDSA", set.Keys[0].Alg)
+	jwk, _ := passport.NewJWK("", "", edPub)
+	assert.Equal(t, jwk.Thumbprint(), set.Keys[0].Kid)
+}
+
+func TestRemoteKeySet(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ring := passport.NewKeyring(passport.NewPrivateKey("k1", ecKey))
+	issuer := passport.New(passport.SetIssuer("dev"), passport.SetKeySet(ring))
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		set, _ := issuer.JWKS()
+		_ = json.NewEncoder(w).Encode(set)
+	}))
+	defer srv.Close()
+
+	keys := passport.NewRemoteKeySet(srv.URL, passport.SetMinRefresh(0))
+	verifier := passport.New(passport.SetIssuer("dev"), passport.SetKeySet(keys))
+
+	ts, err := issuer.Create(passport.NewClaims(userId1, time.Hour))
+	assert.NoError(t, err)
+	claims, err := verifier.Verify(ts)
+	assert.NoError(t, err)
+	assert.Equal(t, userId1, claims.ActiveId)
+	assert.Equal(t, int32(1), hits.Load())
+
+	// Cached
+	_, err = verifier.Verify(ts)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), hits.Load())
+
+	// Unknown kid triggers a refresh
+	ecKey2, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ring.Add(passport.NewPrivateKey("k2", ecKey2))
+	assert.NoError(t, ring.Use("k2"))
+	ts, err = issuer.Create(passport.NewClaims(userId2, time.Hour))
+	assert.NoError(t, err)
+	claims, err = verifier.Verify(ts)
+	assert.NoError(t, err)
+	assert.Equal(t, userId2, claims.ActiveId)
+	assert.Equal(t, int32(2), hits.Load())
+
+	// Verifier cannot sign
+	_, err = verifier.Create(passport.NewClaims(userId1, time.Hour))
+	assert.ErrorIs(t, err, passport.ErrMissingSigningKey)
+
+	_, err = keys.Lookup("unknown")
+	assert.ErrorIs(t, err, passport.ErrUnknownKeyID)
+	assert.NoError(t, keys.Refresh(context.TODO()))
+}
+
+func TestRemoteKeySet_Method(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	for _, method := range []jwt.SigningMethod{jwt.SigningMethodRS512, jwt.SigningMethodPS256} {
+		issuer := passport.New(passport.SetIssuer("dev"), passport.SetPrivateKey(rsaKey), passport.SetMethod(method))
+		set, err := issuer.JWKS()
+		assert.NoError(t, err)
+		assert.Equal(t, method.Alg(), set.Keys[0].Alg)
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(set)
+		}))
+		verifier := passport.New(passport.SetIssuer("dev"), passport.SetKeySet(passport.NewRemoteKeySet(srv.URL)))
+		ts, err := issuer.Create(passport.NewClaims(userId1, time.Hour))
+		assert.NoError(t, err)
+		_, err = verifier.Verify(ts)
+		assert.NoError(t, err)
+		srv.Close()
+	}
+
+	// A method that does not fit the key falls back to the key type
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	x := passport.New(passport.SetIssuer("dev"), passport.SetPublicKeys(edPub), passport.SetMethod(jwt.SigningMethodRS512))
+	set, err := x.JWKS()
+	assert.NoError(t, err)
+	assert.Equal(t, "EdDSA", set.Keys[0].Alg)
+}
+
+func TestRemoteKeySet_MinRefresh(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write([]byte(`{"keys":[]}`))
+	}))
+	defer srv.Close()
+
+	keys := passport.NewRemoteKeySet(srv.URL, passport.SetMinRefresh(time.Minute))
+	_, err := keys.Lookup("a")
+	assert.ErrorIs(t, err, passport.ErrUnknownKeyID)
+	_, err = keys.Lookup("b")
+	assert.ErrorIs(t, err, passport.ErrUnknownKeyID)
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestRemoteKeySet_Unavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	keys := passport.NewRemoteKeySet(srv.URL, passport.SetCacheTTL(time.Minute))
+	_, err := keys.Lookup("a")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, passport.ErrUnknownKeyID)
+	// Lookups within MinRefresh report the failed fetch as well
+	_, err = keys.Lookup("a")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, passport.ErrUnknownKeyID)
+}
+
+func TestRemoteKeySet_SlowRefresh(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	issuer := passport.New(passport.SetIssuer("dev"),
+		passport.SetKeySet(passport.NewKeyring(passport.NewPrivateKey("k1", ecKey))))
+
+	var hits atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every fetch after the first one hangs until released
+		if hits.Add(1) > 1 {
+			<-release
+		}
+		set, _ := issuer.JWKS()
+		_ = json.NewEncoder(w).Encode(set)
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	keys := passport.NewRemoteKeySet(srv.URL, passport.SetCacheTTL(time.Nanosecond), passport.SetMinRefresh(0))
+	_, err := keys.Lookup("k1")
+	assert.NoError(t, err)
+
+	// Expired keys are served while the refresh hangs
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		key, err := keys.Lookup("k1")
+		assert.NoError(t, err)
+		assert.Equal(t, "k1", key.ID)
+	}
+	assert.Less(t, time.Since(start), time.Second)
+
+	// Waiting for an unknown kid is canceled with the context
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	_, err = keys.LookupContext(ctx, "k2")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(2), hits.Load())
+}
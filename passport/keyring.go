@@ -0,0 +1,187 @@
+package passport
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Errors returned by keyring functions.
+var (
+	ErrMissingKeyID = errors.New("passport: token has no key id")
+	ErrUnknownKeyID = errors.New("passport: unknown key id")
+)
+
+// SigningKey is a signing or verification key identified by a kid.
+type SigningKey struct {
+	// ID is the key identifier written to the "kid" header.
+	ID string
+	// Method is the signing method used with this key.
+	Method jwt.SigningMethod
+	// Private signs tokens: []byte for HMAC, crypto.Signer otherwise.
+	// It is nil for verification-only keys.
+	Private interface{}
+	// Public verifies tokens: []byte for HMAC, crypto.PublicKey otherwise.
+	Public interface{}
+}
+
+// NewHMACKey creates an HS256 key from a shared secret.
+// The secret should be at least 32 bytes for security.
+func NewHMACKey(id string, secret string) *SigningKey {
+	return &SigningKey{
+		ID:      id,
+		Method:  jwt.SigningMethodHS256,
+		Private: []byte(secret),
+		Public:  []byte(secret),
+	}
+}
+
+// NewPrivateKey creates an asymmetric key that can sign and verify.
+// The signing method is inferred from the key type; use SetMethod to override.
+func NewPrivateKey(id string, key crypto.Signer) *SigningKey {
+	return &SigningKey{
+		ID:      id,
+		Method:  inferMethod(key.Public()),
+		Private: key,
+		Public:  key.Public(),
+	}
+}
+
+// NewPublicKey creates a verification-only asymmetric key.
+// The signing method is inferred from the key type; use SetMethod to override.
+func NewPublicKey(id string, key crypto.PublicKey) *SigningKey {
+	return &SigningKey{
+		ID:     id,
+		Method: inferMethod(key),
+		Public: key,
+	}
+}
+
+// SetMethod sets the signing method of the key.
+func (x *SigningKey) SetMethod(v jwt.SigningMethod) *SigningKey {
+	x.Method = v
+	return x
+}
+
+// KeySet resolves verification keys by kid.
+type KeySet interface {
+	// Lookup returns the key with the given kid, or ErrUnknownKeyID.
+	Lookup(kid string) (*SigningKey, error)
+}
+
+// ContextKeySet is implemented by key sets that fetch keys, such as RemoteKeySet.
+// VerifyContext uses LookupContext so the request context can cancel waiting.
+type ContextKeySet interface {
+	KeySet
+	LookupContext(ctx context.Context, kid string) (*SigningKey, error)
+}
+
+// Keyring holds multiple keys identified by kid.
+// New tokens are signed with the current key, while tokens issued under
+// previous keys remain valid until those keys are retired.
+//
+//	ring := passport.NewKeyring(passport.NewHMACKey("2024-01", oldSecret))
+//	auth := passport.New(passport.SetIssuer("app"), passport.SetKeySet(ring))
+//
+//	// Rotate: sign with the new key, keep verifying the old one
+//	ring.Add(passport.NewHMACKey("2024-02", newSecret))
+//	ring.Use("2024-02")
+//
+//	// Once all old tokens have expired
+//	ring.Retire("2024-01")
+type Keyring struct {
+	mu      sync.RWMutex
+	current string
+	keys    map[string]*SigningKey
+}
+
+// NewKeyring creates a Keyring with the given keys.
+// The first key that can sign becomes the current key.
+func NewKeyring(keys ...*SigningKey) *Keyring {
+	x := &Keyring{keys: make(map[string]*SigningKey)}
+	x.Add(keys...)
+	for _, v := range keys {
+		if v.Private != nil {
+			x.current = v.ID
+			break
+		}
+	}
+	return x
+}
+
+// Add adds keys to the keyring, replacing any key with the same kid.
+func (x *Keyring) Add(keys ...*SigningKey) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, v := range keys {
+		x.keys[v.ID] = v
+	}
+}
+
+// Use makes the key with the given kid the current signing key.
+// Returns ErrUnknownKeyID if the key does not exist,
+// or ErrMissingSigningKey if it is verification-only.
+func (x *Keyring) Use(kid string) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	key, ok := x.keys[kid]
+	if !ok {
+		return ErrUnknownKeyID
+	}
+	if key.Private == nil {
+		return ErrMissingSigningKey
+	}
+	x.current = kid
+	return nil
+}
+
+// Retire removes the key with the given kid.
+// Tokens signed with a retired key are rejected with ErrUnknownKeyID.
+func (x *Keyring) Retire(kid string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.keys, kid)
+	if x.current == kid {
+		x.current = ""
+	}
+}
+
+// Current returns the key used for signing new tokens.
+func (x *Keyring) Current() (*SigningKey, error) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	key, ok := x.keys[x.current]
+	if !ok {
+		return nil, ErrMissingSigningKey
+	}
+	return key, nil
+}
+
+// Lookup returns the key with the given kid.
+func (x *Keyring) Lookup(kid string) (*SigningKey, error) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	key, ok := x.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	return key, nil
+}
+
+// Keys returns all keys in the keyring sorted by kid.
+func (x *Keyring) Keys() []*SigningKey {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	keys := make([]*SigningKey, 0, len(x.keys))
+	for _, v := range x.keys {
+		keys = append(keys, v)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID < keys[j].ID
+	})
+	return keys
+}
@@ -0,0 +1,95 @@
+package passport_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kainonly/go/passport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyring_Rotation(t *testing.T) {
+	ring := passport.NewKeyring(passport.NewHMACKey("k1", key1))
+	x := passport.New(passport.SetIssuer("dev"), passport.SetKeySet(ring))
+
+	ts1, err := x.Create(passport.NewClaims(userId1, time.Hour))
+	assert.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(ts1, jwt.MapClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", parsed.Header["kid"])
+
+	// Rotate to an asymmetric key
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	ring.Add(passport.NewPrivateKey("k2", ecKey))
+	assert.NoError(t, ring.Use("k2"))
+
+	ts2, err := x.Create(passport.NewClaims(userId2, time.Hour))
+	assert.NoError(t, err)
+	parsed, _, err = jwt.NewParser().ParseUnverified(ts2, jwt.MapClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "k2", parsed.Header["kid"])
+	assert.Equal(t, "ES256", parsed.Method.Alg())
+
+	// Both tokens verify until k1 is retired
+	claims, err := x.Verify(ts1)
+	assert.NoError(t, err)
+	assert.Equal(t, userId1, claims.ActiveId)
+	claims, err = x.Verify(ts2)
+	assert.NoError(t, err)
+	assert.Equal(t, userId2, claims.ActiveId)
+
+	ring.Retire("k1")
+	_, err = x.Verify(ts1)
+	assert.ErrorIs(t, err, passport.ErrUnknownKeyID)
+	_, err = x.Verify(ts2)
+	assert.NoError(t, err)
+
+	assert.Len(t, ring.Keys(), 1)
+}
+
+func TestKeyring_MissingKeyID(t *testing.T) {
+	x := passport.New(
+		passport.SetIssuer("dev"),
+		passport.SetKeySet(passport.NewKeyring(passport.NewHMACKey("k1", key1))),
+	)
+	// Token without kid, signed with the same secret
+	ts, err := x1.Create(passport.NewClaims(userId1, time.Hour))
+	assert.NoError(t, err)
+	_, err = x.Verify(ts)
+	assert.ErrorIs(t, err, passport.ErrMissingKeyID)
+}
+
+func TestKeyring_AlgorithmMismatch(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	x := passport.New(
+		passport.SetIssuer("dev"),
+		passport.SetKeySet(passport.NewKeyring(passport.NewPrivateKey("k1", ecKey))),
+	)
+	// HS256 token claiming the EC key id is rejected
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, passport.NewClaims(userId1, time.Hour))
+	token.Header["kid"] = "k1"
+	ts, err := token.SignedString([]byte(key1))
+	assert.NoError(t, err)
+	_, err = x.Verify(ts)
+	assert.ErrorIs(t, err, passport.ErrInvalidSigningMethod)
+}
+
+func TestKeyring_Use(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	ring := passport.NewKeyring(passport.NewPublicKey("pub", ecKey.Public()))
+	_, err = ring.Current()
+	assert.ErrorIs(t, err, passport.ErrMissingSigningKey)
+	assert.ErrorIs(t, ring.Use("pub"), passport.ErrMissingSigningKey)
+	assert.ErrorIs(t, ring.Use("none"), passport.ErrUnknownKeyID)
+
+	x := passport.New(passport.SetIssuer("dev"), passport.SetKeySet(ring))
+	_, err = x.Create(passport.NewClaims(userId1, time.Hour))
+	assert.ErrorIs(t, err, passport.ErrMissingSigningKey)
+}
@@ -0,0 +1,241 @@
+package passport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kainonly/go/help"
+)
+
+// ErrMissingToken is returned when no extractor finds a token in the request.
+var ErrMissingToken = errors.New("passport: missing token")
+
+// tokenErrors are the errors caused by the presented token or proof.
+// Other errors, such as a Redis failure of the Revoker or a failed JWKS fetch,
+// are server errors. jwt.ErrTokenUnverifiable is not listed: it wraps keyfunc
+// errors, whose token-related causes are listed themselves.
+var tokenErrors = []error{
+	ErrMissingToken,
+	ErrTokenExpired,
+	ErrTokenNotValidYet,
+	ErrTokenUsedBeforeIssued,
+	ErrInvalidAudience,
+	ErrMissingClaim,
+	ErrTokenTooOld,
+	ErrInvalidIssuer,
+	ErrUnknownIssuer,
+	ErrInvalidSigningMethod,
+	ErrMissingKeyID,
+	ErrUnknownKeyID,
+	ErrTokenRevoked,
+	ErrInvalidJWE,
+	ErrDecryption,
+	ErrNotEncrypted,
+	ErrUnsupportedEncryption,
+	ErrMissingProof,
+	ErrInvalidProof,
+	ErrProofReplayed,
+	ErrProofKeyMismatch,
+	jwt.ErrTokenMalformed,
+	jwt.ErrTokenSignatureInvalid,
+	jwt.ErrSignatureInvalid,
+	jwt.ErrTokenInvalidClaims,
+}
+
+// isTokenError reports whether err is caused by the presented token.
+func isTokenError(err error) bool {
+	for _, v := range tokenErrors {
+		if errors.Is(err, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClaimsKey is the RequestContext key under which Authenticate stores Claims.
+const ClaimsKey = "passport:claims"
+
+// Verifier verifies a token string and returns its claims.
+// Passport implements Verifier.
+type Verifier interface {
+	VerifyContext(ctx context.Context, tokenString string) (Claims, error)
+}
+
+// Extractor extracts a token from the request.
+// It returns an empty string if the token is not present.
+type Extractor func(c *app.RequestContext) string
+
+// FromBearer extracts the token from the "Authorization: Bearer <token>" header.
+func FromBearer() Extractor {
+	return func(c *app.RequestContext) string {
+		v := string(c.GetHeader("Authorization"))
+		if len(v) > 7 && strings.EqualFold(v[:7], "Bearer ") {
+			return strings.TrimSpace(v[7:])
+		}
+		return ""
+	}
+}
+
+// FromDPoP extracts the token from the "Authorization: DPoP <token>" header
+// used by sender-constrained tokens.
+func FromDPoP() Extractor {
+	return func(c *app.RequestContext) string {
+		v := string(c.GetHeader("Authorization"))
+		if len(v) > 5 && strings.EqualFold(v[:5], "DPoP ") {
+			return strings.TrimSpace(v[5:])
+		}
+		return ""
+	}
+}
+
+// FromCookie extracts the token from the named cookie.
+func FromCookie(name string) Extractor {
+	return func(c *app.RequestContext) string {
+		return string(c.Cookie(name))
+	}
+}
+
+// FromQuery extracts the token from the named query parameter.
+// Tokens in URLs end up in logs and browser history, prefer headers or cookies.
+func FromQuery(name string) Extractor {
+	return func(c *app.RequestContext) string {
+		return c.Query(name)
+	}
+}
+
+// Authenticator is a Hertz middleware that verifies tokens and stores Claims
+// in the RequestContext.
+//
+//	authn := passport.NewAuthenticator(auth,
+//		passport.SetExtractors(passport.FromBearer(), passport.FromCookie("access_token")),
+//	)
+//	api := h.Group("/api", authn.Authenticate())
+//	api.GET("/profile", func(ctx context.Context, c *app.RequestContext) {
+//		claims, _ := passport.GetClaims(c)
+//		...
+//	})
+type Authenticator struct {
+	// Verifier verifies extracted tokens.
+	Verifier Verifier
+	// Extractors are tried in order; the first non-empty token is used
+	// (default: FromBearer).
+	Extractors []Extractor
+	// Code is the error code in the response body (default: 0).
+	Code int64
+	// Proof verifies DPoP proofs of tokens with a cnf claim (default: nil).
+	// Without it, bound tokens are rejected.
+	Proof *ProofVerifier
+}
+
+// NewAuthenticator creates a new Authenticator with the given Verifier.
+func NewAuthenticator(verifier Verifier, options ...AuthOption) *Authenticator {
+	x := &Authenticator{
+		Verifier:   verifier,
+		Extractors: []Extractor{FromBearer()},
+	}
+	for _, opt := range options {
+		opt(x)
+	}
+	return x
+}
+
+// AuthOption is a function that configures an Authenticator instance.
+type AuthOption func(x *Authenticator)
+
+// SetExtractors sets where tokens are looked up, in order of precedence.
+func SetExtractors(v ...Extractor) AuthOption {
+	return func(x *Authenticator) {
+		x.Extractors = v
+	}
+}
+
+// SetErrorCode sets the error code returned in the response body.
+func SetErrorCode(v int64) AuthOption {
+	return func(x *Authenticator) {
+		x.Code = v
+	}
+}
+
+// SetProofVerifier enables sender-constrained tokens.
+// Tokens with a cnf claim are only accepted with a valid DPoP proof of the bound key.
+func SetProofVerifier(v *ProofVerifier) AuthOption {
+	return func(x *Authenticator) {
+		x.Proof = v
+	}
+}
+
+// Extract returns the first token found by the extractors.
+func (x *Authenticator) Extract(c *app.RequestContext) string {
+	for _, extract := range x.Extractors {
+		if v := extract(c); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Authenticate returns a Hertz middleware that verifies the request token.
+// On success the Claims are stored under ClaimsKey; on an invalid token the
+// request is aborted with 401 and a help.R body. Other errors (e.g. Redis
+// failures of the Revoker) abort with 500 and are recorded with c.Error for
+// help.ErrorHandler, so an outage is not mistaken for a logout.
+func (x *Authenticator) Authenticate() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		token := x.Extract(c)
+		if token == "" {
+			x.abort(c, ErrMissingToken)
+			return
+		}
+		claims, err := x.Verifier.VerifyContext(ctx, token)
+		if err != nil {
+			x.abort(c, err)
+			return
+		}
+		if claims.Confirmation != nil {
+			if x.Proof == nil {
+				x.abort(c, ErrMissingProof)
+				return
+			}
+			if err = x.Proof.Confirm(ctx, c, token, claims.Confirmation); err != nil {
+				x.abort(c, err)
+				return
+			}
+		}
+		c.Set(ClaimsKey, claims)
+		c.Next(ctx)
+	}
+}
+
+func (x *Authenticator) abort(c *app.RequestContext, err error) {
+	if !isTokenError(err) {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if x.Proof != nil {
+		c.Header("WWW-Authenticate", "DPoP, Bearer")
+	} else {
+		c.Header("WWW-Authenticate", "Bearer")
+	}
+	c.AbortWithStatusJSON(http.StatusUnauthorized, help.Fail(x.Code, err.Error()))
+}
+
+// GetClaims returns the Claims stored by Authenticate.
+func GetClaims(c *app.RequestContext) (Claims, bool) {
+	v, ok := c.Get(ClaimsKey)
+	if !ok {
+		return Claims{}, false
+	}
+	claims, ok := v.(Claims)
+	return claims, ok
+}
+
+// GetActiveId returns the ActiveId of the Claims stored by Authenticate,
+// or an empty string if the request is not authenticated.
+func GetActiveId(c *app.RequestContext) string {
+	claims, _ := GetClaims(c)
+	return claims.ActiveId
+}
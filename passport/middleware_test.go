@@ -0,0 +1,144 @@
+package passport_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kainonly/go/help"
+	"github.com/kainonly/go/passport"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAuthRouter(authn *passport.Authenticator) *route.Engine {
+	router := route.NewEngine(config.NewOptions([]config.Option{}))
+	router.GET("/api", authn.Authenticate(), func(ctx context.Context, c *app.RequestContext) {
+		claims, ok := passport.GetClaims(c)
+		c.JSON(http.StatusOK, utils.H{
+			"ok":        ok,
+			"active_id": passport.GetActiveId(c),
+			"jti":       claims.ID,
+		})
+	})
+	return router
+}
+
+func TestAuthenticate_Bearer(t *testing.T) {
+	ts, err := x1.Create(passport.NewClaims(userId1, time.Hour).SetJTI(jti1))
+	assert.NoError(t, err)
+	router := newAuthRouter(passport.NewAuthenticator(x1))
+
+	w := ut.PerformRequest(router, "GET", "/api", &ut.Body{Body: bytes.NewBuffer(nil)},
+		ut.Header{Key: "Authorization", Value: "Bearer " + ts})
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(resp.Body(), &body))
+	assert.Equal(t, true, body["ok"])
+	assert.Equal(t, userId1, body["active_id"])
+	assert.Equal(t, jti1, body["jti"])
+}
+
+func TestAuthenticate_Missing(t *testing.T) {
+	router := newAuthRouter(passport.NewAuthenticator(x1, passport.SetErrorCode(40100)))
+	w := ut.PerformRequest(router, "GET", "/api", &ut.Body{Body: bytes.NewBuffer(nil)})
+	resp := w.Result()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode())
+	assert.Equal(t, "Bearer", string(resp.Header.Peek("WWW-Authenticate")))
+	var r help.R
+	assert.NoError(t, json.Unmarshal(resp.Body(), &r))
+	assert.Equal(t, int64(40100), r.Code)
+	assert.Equal(t, passport.ErrMissingToken.Error(), r.Message)
+}
+
+func TestAuthenticate_Invalid(t *testing.T) {
+	router := newAuthRouter(passport.NewAuthenticator(x1))
+	otherTs, err := x2.Create(passport.NewClaims(userId2, time.Hour))
+	assert.NoError(t, err)
+	for _, v := range []string{"Bearer invalid", "Bearer " + otherTs, "Basic abc"} {
+		w := ut.PerformRequest(router, "GET", "/api", &ut.Body{Body: bytes.NewBuffer(nil)},
+			ut.Header{Key: "Authorization", Value: v})
+		assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode())
+	}
+}
+
+func TestAuthenticate_UnknownAlg(t *testing.T) {
+	router := newAuthRouter(passport.NewAuthenticator(x1))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"dev","active_id":"` + userId1 + `"}`))
+	for _, header := range []string{`{"alg":"XYZ","typ":"JWT"}`, `{"typ":"JWT"}`} {
+		ts := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + payload + ".c2ln"
+		w := ut.PerformRequest(router, "GET", "/api", &ut.Body{Body: bytes.NewBuffer(nil)},
+			ut.Header{Key: "Authorization", Value: "Bearer " + ts})
+		assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode())
+
+		_, err := x1.Verify(ts)
+		assert.ErrorIs(t, err, passport.ErrInvalidSigningMethod)
+	}
+}
+
+// verifierFunc adapts a function to passport.Verifier.
+type verifierFunc func(ctx context.Context, tokenString string) (passport.Claims, error)
+
+func (f verifierFunc) VerifyContext(ctx context.Context, tokenString string) (passport.Claims, error) {
+	return f(ctx, tokenString)
+}
+
+func TestAuthenticate_ServerError(t *testing.T) {
+	var verr error
+	router := newAuthRouter(passport.NewAuthenticator(verifierFunc(
+		func(ctx context.Context, tokenString string) (passport.Claims, error) {
+			return passport.Claims{}, verr
+		})))
+	request := func() *ut.ResponseRecorder {
+		return ut.PerformRequest(router, "GET", "/api", &ut.Body{Body: bytes.NewBuffer(nil)},
+			ut.Header{Key: "Authorization", Value: "Bearer token"})
+	}
+
+	// Infrastructure errors are not reported as an invalid token
+	verr = errors.New("dial tcp 10.0.0.5:6379: connect: connection refused")
+	w := request()
+	assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode())
+	assert.NotContains(t, string(w.Result().Body()), "10.0.0.5")
+
+	verr = fmt.Errorf("%w: %w", passport.ErrTokenExpired, jwt.ErrTokenExpired)
+	assert.Equal(t, http.StatusUnauthorized, request().Result().StatusCode())
+	verr = passport.ErrTokenRevoked
+	assert.Equal(t, http.StatusUnauthorized, request().Result().StatusCode())
+}
+
+func TestAuthenticate_Extractors(t *testing.T) {
+	ts, err := x1.Create(passport.NewClaims(userId1, time.Hour))
+	assert.NoError(t, err)
+	router := newAuthRouter(passport.NewAuthenticator(x1, passport.SetExtractors(
+		passport.FromCookie("access_token"),
+		passport.FromQuery("token"),
+		passport.FromBearer(),
+	)))
+
+	// Cookie
+	w := ut.PerformRequest(router, "GET", "/api", &ut.Body{Body: bytes.NewBuffer(nil)},
+		ut.Header{Key: "Cookie", Value: "access_token=" + ts})
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode())
+
+	// Query
+	w = ut.PerformRequest(router, "GET", "/api?token="+ts, &ut.Body{Body: bytes.NewBuffer(nil)})
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode())
+
+	// Cookie takes precedence over the header
+	w = ut.PerformRequest(router, "GET", "/api", &ut.Body{Body: bytes.NewBuffer(nil)},
+		ut.Header{Key: "Cookie", Value: "access_token=invalid"},
+		ut.Header{Key: "Authorization", Value: "Bearer " + ts})
+	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode())
+}
@@ -1,6 +1,9 @@
 // Package passport provides JWT (JSON Web Token) authentication utilities for Hertz.
 //
-// It uses HS256 (HMAC-SHA256) for token signing and supports custom claims.
+// It uses HS256 (HMAC-SHA256) for token signing by default and supports custom claims.
+// Asymmetric algorithms (RS256, ES256, EdDSA, SM2, ...) can be enabled with a private key
+// for signing and public keys for verification, so that services verifying tokens
+// never hold the signing secret.
 //
 // # Hertz Backend Setup
 //
@@ -24,30 +27,165 @@
 //		c.JSON(200, utils.H{"accessToken": token})
 //	})
 //
-//	// Auth middleware - verify JWT token
-//	func AuthMiddleware(auth *passport.Passport) app.HandlerFunc {
-//		return func(ctx context.Context, c *app.RequestContext) {
-//			token := c.GetHeader("Authorization")
-//			if token == nil {
-//				c.AbortWithStatusJSON(401, utils.H{"error": "missing token"})
-//				return
-//			}
-//			// Remove "Bearer " prefix
-//			tokenStr := strings.TrimPrefix(string(token), "Bearer ")
-//			claims, err := auth.Verify(tokenStr)
-//			if err != nil {
-//				c.AbortWithStatusJSON(401, utils.H{"error": err.Error()})
-//				return
-//			}
-//			c.Set("userId", claims.ActiveId)
-//			c.Set("claims", claims)
-//			c.Next(ctx)
-//		}
-//	}
+//	// Auth middleware - verify JWT token from "Authorization: Bearer" (default)
+//	authn := passport.NewAuthenticator(auth)
 //
 //	// Protected routes
-//	api := h.Group("/api", AuthMiddleware(auth))
-//	api.GET("/profile", profileHandler)
+//	api := h.Group("/api", authn.Authenticate())
+//	api.GET("/profile", func(ctx context.Context, c *app.RequestContext) {
+//		claims, _ := passport.GetClaims(c)
+//		c.JSON(200, utils.H{"userId": claims.ActiveId})
+//	})
+//
+// Tokens can also be read from a cookie or query parameter; extractors are tried in order:
+//
+//	authn := passport.NewAuthenticator(auth, passport.SetExtractors(
+//		passport.FromBearer(),
+//		passport.FromCookie("access_token"),
+//	))
+//
+// Failures are aborted with 401 and a help.R body ({"code": 0, "message": "..."}).
+//
+// # Authorization
+//
+// An Authorizer reads scopes and roles from Claims.Data and rejects requests
+// with 403 on routes or route groups:
+//
+//	authz := passport.NewAuthorizer(passport.SetRoles(map[string]int{"viewer": 1, "editor": 2, "admin": 3}))
+//	admin := api.Group("/admin", authz.RequireRole("editor"))
+//	api.GET("/orders", authz.RequireAnyScope("orders:read"), listOrders)
+//
+// # Typed Claims
+//
+// TypedClaims carries a user-defined struct instead of map[string]interface{}:
+//
+//	token, err := passport.CreateTyped(auth, passport.NewTypedClaims(userId, time.Hour, Session{Role: "admin"}))
+//	claims, err := passport.VerifyTyped[Session](auth, token)
+//
+// # Validation
+//
+// Verify always checks exp, nbf and iat. Stricter rules are opt-in, and each
+// failure has its own sentinel error (ErrTokenExpired, ErrInvalidAudience,
+// ErrMissingClaim, ErrTokenTooOld, ...):
+//
+//	auth := passport.New(
+//		passport.SetKey("your-secret-key-at-least-32-bytes"),
+//		passport.SetIssuer("your-app-name"),
+//		passport.SetAudience("api"),
+//		passport.SetRequiredClaims("jti"),
+//		passport.SetLeeway(30*time.Second),
+//		passport.SetMaxAge(24*time.Hour),
+//	)
+//
+// # Inspection
+//
+// Inspect decodes a token without trusting it and reports the step that failed
+// (signature, expiry, issuer, audience, revocation, ...) and the time remaining.
+// IntrospectHandler serves RFC 7662 introspection for internal services:
+//
+//	i := auth.Inspect(ctx, token) // i.Step, i.Err, i.Header, i.Claims, i.Remaining
+//	internal.POST("/introspect", auth.IntrospectHandler())
+//
+// # Encrypted Tokens
+//
+// Signed tokens are readable by anyone holding them. With an Encrypter, the
+// signed JWT is nested inside a compact JWE so Data is hidden from clients:
+//
+//	enc, err := passport.NewEncrypter(encryptionKey) // 32-byte []byte, RSA or EC key
+//	auth := passport.New(..., passport.SetEncrypter(enc))
+//
+// # Asymmetric Signing
+//
+//	// Issuer - signs with the private key (public key is derived automatically)
+//	priv, _ := jwt.ParseECPrivateKeyFromPEM(privPEM)
+//	auth := passport.New(
+//		passport.SetIssuer("your-app-name"),
+//		passport.SetMethod(jwt.SigningMethodES256),
+//		passport.SetPrivateKey(priv),
+//	)
+//
+//	// Verifier - only holds the public key
+//	pub, _ := jwt.ParseECPublicKeyFromPEM(pubPEM)
+//	verifier := passport.New(
+//		passport.SetIssuer("your-app-name"),
+//		passport.SetPublicKeys(pub),
+//	)
+//
+// Only algorithms in the allow-list are accepted by Verify. The allow-list defaults
+// to the configured signing method and can be widened with SetAlgorithms.
+//
+// SM2 with SM3 (GM/T) is available as SigningMethodSM2 ("alg": "SM2") and is
+// inferred from keys loaded with help.PrivKeySM2FromBase64 and help.PubKeySM2FromBase64:
+//
+//	priv, _ := help.PrivKeySM2FromBase64(privKeyBase64)
+//	auth := passport.New(passport.SetIssuer("your-app-name"), passport.SetPrivateKey(priv))
+//
+// # JWKS
+//
+// Services that only verify tokens can discover public keys from a JWKS endpoint:
+//
+//	// Issuer
+//	h.GET("/.well-known/jwks.json", auth.JWKSHandler())
+//
+//	// Verifier - keys are cached and refreshed on expiry or unknown kid
+//	keys := passport.NewRemoteKeySet("https://auth.example.com/.well-known/jwks.json")
+//	verifier := passport.New(passport.SetIssuer("your-app-name"), passport.SetKeySet(keys))
+//
+// # Multiple Issuers
+//
+// Issuers routes tokens on their iss claim to a per-issuer Passport, each with
+// its own keys, algorithms and audience. It implements Verifier:
+//
+//	issuers := passport.NewIssuers(auth, partnerA, partnerB)
+//	claims, issuer, err := issuers.Verify(token)
+//	authn := passport.NewAuthenticator(issuers) // passport.GetIssuer(c) in handlers
+//
+// # Refresh Tokens
+//
+// A Refresher issues access/refresh token pairs and rotates the refresh token on
+// every use. Reusing a rotated refresh token revokes the whole token family.
+//
+//	refresher := passport.NewRefresher(redisClient, auth)
+//	pair, err := refresher.Issue(ctx, userId, data)       // login
+//	pair, err = refresher.Refresh(ctx, pair.RefreshToken) // renew
+//
+// # Revocation
+//
+// A Revoker denylists tokens by JTI, or all tokens of an ActiveId issued before
+// a cutoff time. VerifyContext consults it after the signature checks.
+//
+//	revoker := passport.NewRevoker(redisClient)
+//	auth := passport.New(..., passport.SetRevoker(revoker))
+//	revoker.Revoke(ctx, claims)                       // logout
+//	revoker.RevokeBefore(ctx, userId, time.Now())     // log out everywhere
+//	claims, err := auth.VerifyContext(ctx, token)     // ErrTokenRevoked
+//
+// # Sender-Constrained Tokens
+//
+// A token with a cnf claim is bound to a client key and requires a DPoP proof
+// (RFC 9449) signed by that key on every request, so a leaked token cannot be
+// replayed. Proof nonces are stored in Redis to reject replayed proofs.
+//
+//	proofs := passport.NewProofVerifier(redisClient)
+//	proof, err := proofs.VerifyRequest(ctx, c, "")   // login request
+//	token, err := auth.Create(passport.NewClaims(userId, time.Hour).SetConfirmation(proof.JKT))
+//	authn := passport.NewAuthenticator(auth,
+//		passport.SetExtractors(passport.FromDPoP(), passport.FromBearer()),
+//		passport.SetProofVerifier(proofs),
+//	)
+//
+// # Key Rotation
+//
+// A Keyring holds several keys identified by kid. Create signs with the current
+// key and writes its kid to the token header; Verify looks the kid up and rejects
+// unknown or retired keys. HMAC and asymmetric keys can be mixed.
+//
+//	ring := passport.NewKeyring(passport.NewHMACKey("k1", oldSecret))
+//	auth := passport.New(passport.SetIssuer("your-app-name"), passport.SetKeySet(ring))
+//
+//	ring.Add(passport.NewPrivateKey("k2", ecKey))
+//	ring.Use("k2")    // new tokens are signed with k2, k1 tokens still verify
+//	ring.Retire("k1") // once every k1 token has expired
 //
 // # Angular Frontend Setup
 //
@@ -79,13 +217,21 @@
 //
 //   - Use a strong secret key (at least 32 bytes)
 //   - Set appropriate token expiration time
-//   - Store tokens securely on client side
+//   - Store tokens securely on client side; bind them with DPoP if they live in localStorage
 //   - Use HTTPS in production
-//   - Consider implementing token refresh for long sessions
+//   - Use short-lived access tokens with a Refresher for long sessions
 package passport
 
 import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
 	"errors"
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -93,26 +239,135 @@ import (
 
 // Errors returned by passport functions.
 var (
-	ErrInvalidSigningMethod = errors.New("passport: invalid signing method, expected HS256")
+	ErrInvalidSigningMethod = errors.New("passport: invalid signing method, not in the allowed algorithms")
 	ErrInvalidIssuer        = errors.New("passport: token issuer does not match")
+	ErrMissingSigningKey    = errors.New("passport: no signing key configured")
 )
 
 // Passport provides JWT token creation and verification.
 type Passport struct {
 	Issuer string
-	Key    string
+	// Key is the shared secret for HMAC methods (HS256 by default).
+	Key string
+	// Method is the signing method used by Create (default: HS256).
+	Method jwt.SigningMethod
+	// PrivateKey signs tokens when Method is asymmetric.
+	PrivateKey crypto.Signer
+	// PublicKeys verify tokens signed with an asymmetric method.
+	PublicKeys []crypto.PublicKey
+	// Algorithms is the allow-list of "alg" values accepted by Verify.
+	// Defaults to the algorithm of Method.
+	Algorithms []string
+	// KeySet resolves keys by the "kid" header and takes precedence over
+	// Key, PrivateKey and PublicKeys when set. See Keyring.
+	KeySet KeySet
+	// Revoker is the denylist consulted by VerifyContext.
+	Revoker *Revoker
+	// Audience is written to the aud claim by Create; Verify requires
+	// the token to contain at least one of these values.
+	Audience []string
+	// RequiredClaims are claim names that must be present, see SetRequiredClaims.
+	RequiredClaims []string
+	// Leeway is the clock skew tolerated for exp, nbf and iat.
+	Leeway time.Duration
+	// MaxAge rejects tokens issued longer ago than this, regardless of exp.
+	MaxAge time.Duration
+	// Encrypter wraps signed tokens in a JWE when set.
+	Encrypter *Encrypter
 }
 
 // New creates a new Passport instance with the given options.
 // Both SetKey and SetIssuer should be provided for proper operation.
+// When only asymmetric keys are given, the signing method is inferred
+// from the key type unless SetMethod is used.
+// It panics if RequiredClaims contains an unsupported name.
 func New(options ...Option) *Passport {
 	x := new(Passport)
 	for _, v := range options {
 		v(x)
 	}
+	x.Method, x.Algorithms = x.method(), x.algorithms()
+	for _, name := range x.RequiredClaims {
+		if !slices.Contains(claimNames, name) {
+			panic(fmt.Sprintf("passport: unsupported required claim %q", name))
+		}
+	}
 	return x
 }
 
+// method returns Method, or the default used when it is nil: the method
+// inferred from PrivateKey or the first of PublicKeys, else HS256.
+// Passports built as struct literals rely on it.
+func (x *Passport) method() jwt.SigningMethod {
+	if x.Method != nil {
+		return x.Method
+	}
+	if x.PrivateKey != nil {
+		return inferMethod(x.PrivateKey.Public())
+	}
+	if len(x.PublicKeys) != 0 {
+		return inferMethod(x.PublicKeys[0])
+	}
+	return jwt.SigningMethodHS256
+}
+
+// algorithms returns Algorithms, or the alg of the method when it is empty.
+// With a KeySet each token must match the method of its own key,
+// so the allow-list is only applied when set explicitly.
+func (x *Passport) algorithms() []string {
+	if len(x.Algorithms) == 0 && x.KeySet == nil {
+		return []string{x.method().Alg()}
+	}
+	return x.Algorithms
+}
+
+// inferMethod returns the default signing method for a public key type.
+// Unknown key types fall back to HS256.
+func inferMethod(key crypto.PublicKey) jwt.SigningMethod {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		if isSM2(k) {
+			return SigningMethodSM2
+		}
+		switch k.Curve {
+		case elliptic.P384():
+			return jwt.SigningMethodES384
+		case elliptic.P521():
+			return jwt.SigningMethodES512
+		}
+		return jwt.SigningMethodES256
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodHS256
+}
+
+// methodFor returns m if it can verify with the public key,
+// and the default method of the key type otherwise.
+func methodFor(m jwt.SigningMethod, key crypto.PublicKey) jwt.SigningMethod {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		switch m.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return m
+		}
+	case *ecdsa.PublicKey:
+		if isSM2(k) {
+			break
+		}
+		if v, ok := m.(*jwt.SigningMethodECDSA); ok && v.CurveBits == k.Curve.Params().BitSize {
+			return m
+		}
+	case ed25519.PublicKey:
+		if _, ok := m.(*jwt.SigningMethodEd25519); ok {
+			return m
+		}
+	}
+	return inferMethod(key)
+}
+
 // Option is a function that configures a Passport instance.
 type Option func(x *Passport)
 
@@ -132,16 +387,119 @@ func SetKey(v string) Option {
 	}
 }
 
+// SetMethod sets the signing method used by Create.
+// Default is HS256, or the method matching the configured key type.
+func SetMethod(v jwt.SigningMethod) Option {
+	return func(x *Passport) {
+		x.Method = v
+	}
+}
+
+// SetPrivateKey sets the private key for asymmetric signing.
+// The matching public key is added to the verification keys automatically.
+func SetPrivateKey(v crypto.Signer) Option {
+	return func(x *Passport) {
+		x.PrivateKey = v
+		x.PublicKeys = append(x.PublicKeys, v.Public())
+	}
+}
+
+// SetPublicKeys adds public keys for verifying asymmetric tokens.
+// A token is accepted if any of the keys verifies its signature.
+func SetPublicKeys(v ...crypto.PublicKey) Option {
+	return func(x *Passport) {
+		x.PublicKeys = append(x.PublicKeys, v...)
+	}
+}
+
+// SetAlgorithms sets the allow-list of "alg" values accepted by Verify.
+// Tokens signed with any other algorithm are rejected with ErrInvalidSigningMethod.
+func SetAlgorithms(v ...string) Option {
+	return func(x *Passport) {
+		x.Algorithms = v
+	}
+}
+
+// SetKeySet sets the keys resolved by the "kid" header.
+// If the KeySet also provides a current key (such as Keyring),
+// Create signs with it and writes its kid to the token header.
+func SetKeySet(v KeySet) Option {
+	return func(x *Passport) {
+		x.KeySet = v
+	}
+}
+
+// SetRevoker sets the denylist consulted by VerifyContext.
+func SetRevoker(v *Revoker) Option {
+	return func(x *Passport) {
+		x.Revoker = v
+	}
+}
+
+// SetAudience sets the audience (aud claim).
+// Create writes it to tokens without an audience, and Verify rejects tokens
+// not intended for any of the given values with ErrInvalidAudience.
+func SetAudience(v ...string) Option {
+	return func(x *Passport) {
+		x.Audience = v
+	}
+}
+
+// SetRequiredClaims sets claims that must be present in verified tokens.
+// Supported names: "jti", "sub", "aud", "exp", "iat", "nbf" and "active_id".
+// Names are case-sensitive and New panics on any other name.
+// Missing claims are rejected with ErrMissingClaim.
+func SetRequiredClaims(v ...string) Option {
+	return func(x *Passport) {
+		x.RequiredClaims = v
+	}
+}
+
+// SetLeeway sets the clock skew tolerated when validating exp, nbf and iat.
+func SetLeeway(v time.Duration) Option {
+	return func(x *Passport) {
+		x.Leeway = v
+	}
+}
+
+// SetMaxAge sets the maximum token age measured from iat.
+// Older tokens are rejected with ErrTokenTooOld even if not yet expired.
+// Tokens without iat are rejected when MaxAge is set.
+func SetMaxAge(v time.Duration) Option {
+	return func(x *Passport) {
+		x.MaxAge = v
+	}
+}
+
+// SetEncrypter enables encrypted tokens.
+// Create nests the signed JWT inside a compact JWE, and Verify decrypts
+// before validating. Unencrypted tokens are rejected with ErrNotEncrypted.
+func SetEncrypter(v *Encrypter) Option {
+	return func(x *Passport) {
+		x.Encrypter = v
+	}
+}
+
 // Claims represents the JWT claims with custom fields.
 type Claims struct {
 	// ActiveId is the primary identifier (usually user ID or session ID).
 	ActiveId string `json:"active_id,omitempty"`
 	// Data holds additional custom data.
 	Data map[string]interface{} `json:"data,omitempty"`
+	// Confirmation binds the token to a client key (RFC 7800 cnf claim).
+	Confirmation *Confirmation `json:"cnf,omitempty"`
 
 	jwt.RegisteredClaims
 }
 
+func (x *Claims) registered() *jwt.RegisteredClaims {
+	return &x.RegisteredClaims
+}
+
+func (x *Claims) activeId() string {
+	return x.ActiveId
+}
+
 // NewClaims creates a new Claims with the given activeId and expiration duration.
 // It sets IssuedAt and NotBefore to current time.
 func NewClaims(activeId string, expire time.Duration) *Claims {
@@ -170,32 +528,148 @@ func (x *Claims) SetData(v map[string]interface{}) *Claims {
 	return x
 }
 
+// SetConfirmation binds the token to the client key with the given JWK thumbprint.
+// A bound token is only accepted together with a DPoP proof signed by that key.
+func (x *Claims) SetConfirmation(jkt string) *Claims {
+	x.Confirmation = &Confirmation{JKT: jkt}
+	return x
+}
+
 // Create generates a signed JWT token string from the given claims.
-// The token is signed using HS256 algorithm.
+// The token is signed using the configured Method (HS256 by default).
 func (x *Passport) Create(claims *Claims) (string, error) {
-	claims.RegisteredClaims.Issuer = x.Issuer
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(x.Key))
+	x.prepare(&claims.RegisteredClaims)
+	return x.sign(claims)
+}
+
+// prepare sets the issuer and default audience before signing.
+func (x *Passport) prepare(rc *jwt.RegisteredClaims) {
+	rc.Issuer = x.Issuer
+	if len(rc.Audience) == 0 {
+		rc.Audience = x.Audience
+	}
+}
+
+// sign signs any claims with the configured method and key,
+// and encrypts the result if an Encrypter is configured.
+func (x *Passport) sign(claims jwt.Claims) (string, error) {
+	token, err := x.signJWS(claims)
+	if err != nil || x.Encrypter == nil {
+		return token, err
+	}
+	return x.Encrypter.Encrypt([]byte(token), "JWT")
+}
+
+func (x *Passport) signJWS(claims jwt.Claims) (string, error) {
+	if x.KeySet != nil {
+		ks, ok := x.KeySet.(interface{ Current() (*SigningKey, error) })
+		if !ok {
+			return "", ErrMissingSigningKey
+		}
+		current, err := ks.Current()
+		if err != nil {
+			return "", err
+		}
+		token := jwt.NewWithClaims(current.Method, claims)
+		token.Header["kid"] = current.ID
+		return token.SignedString(current.Private)
+	}
+	method := x.method()
+	token := jwt.NewWithClaims(method, claims)
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		if x.Key == "" {
+			return "", ErrMissingSigningKey
+		}
+		return token.SignedString([]byte(x.Key))
+	}
+	if x.PrivateKey == nil {
+		return "", ErrMissingSigningKey
+	}
+	// The thumbprint matches the kid published by JWKS
+	if kid, err := thumbprint(x.PrivateKey.Public()); err == nil {
+		token.Header["kid"] = kid
+	}
+	return token.SignedString(x.PrivateKey)
+}
+
+// keyFunc resolves the verification key for a parsed token.
+// The algorithm must be in the allow-list; HMAC tokens are checked against Key
+// and asymmetric tokens against PublicKeys, so a public key can never be
+// used as an HMAC secret.
+func (x *Passport) keyFunc(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	if x.KeySet != nil {
+		return x.lookupKey(ctx, token)
+	}
+	if !slices.Contains(x.algorithms(), token.Method.Alg()) {
+		return nil, ErrInvalidSigningMethod
+	}
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if x.Key == "" {
+			return nil, ErrMissingSigningKey
+		}
+		return []byte(x.Key), nil
+	}
+	set := jwt.VerificationKeySet{}
+	for _, v := range x.PublicKeys {
+		set.Keys = append(set.Keys, v)
+	}
+	return set, nil
+}
+
+// lookupKey resolves the key named by the "kid" header from the KeySet.
+// The token algorithm must match the method of that key.
+func (x *Passport) lookupKey(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrMissingKeyID
+	}
+	var key *SigningKey
+	var err error
+	if ks, ok := x.KeySet.(ContextKeySet); ok {
+		key, err = ks.LookupContext(ctx, kid)
+	} else {
+		key, err = x.KeySet.Lookup(kid)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, ErrInvalidSigningMethod
+	}
+	if len(x.Algorithms) != 0 && !slices.Contains(x.Algorithms, token.Method.Alg()) {
+		return nil, ErrInvalidSigningMethod
+	}
+	return key.Public, nil
 }
 
 // Verify parses and validates a JWT token string.
-// It checks the signing method (HS256 only) and verifies the issuer matches.
+// It checks the signing method against the allowed algorithms, the time claims
+// (with Leeway), the issuer, and the audience, required claims and MaxAge when configured.
 // Returns the claims if valid, or an error if invalid.
 func (x *Passport) Verify(tokenString string) (Claims, error) {
 	var claims Claims
-	_, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
-		// Strict check for HS256 only
-		if token.Method != jwt.SigningMethodHS256 {
-			return nil, ErrInvalidSigningMethod
-		}
-		return []byte(x.Key), nil
-	})
-	if err != nil {
+	err := x.parse(context.Background(), tokenString, &claims)
+	return claims, err
+}
+
+// VerifyContext verifies the token like Verify and additionally checks
+// the Revoker denylist if one is configured.
+// Returns ErrTokenRevoked if the token was revoked.
+func (x *Passport) VerifyContext(ctx context.Context, tokenString string) (Claims, error) {
+	var claims Claims
+	if err := x.parse(ctx, tokenString, &claims); err != nil {
 		return claims, err
 	}
-	// Verify issuer matches
-	if claims.Issuer != x.Issuer {
-		return claims, ErrInvalidIssuer
+	if err := x.checkRevoked(ctx, &claims); err != nil {
+		return claims, err
 	}
 	return claims, nil
 }
+
+func (x *Passport) checkRevoked(ctx context.Context, claims tokenClaims) error {
+	if x.Revoker == nil {
+		return nil
+	}
+	rc := claims.registered()
+	return x.Revoker.check(ctx, rc.ID, claims.activeId(), rc.IssuedAt)
+}
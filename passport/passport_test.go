@@ -1,17 +1,26 @@
 package passport_test
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/kainonly/go/passport"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 )
 
 var x1 *passport.Passport
 var x2 *passport.Passport
+var rdb *redis.Client
 
 var key1 = "hZXD^@K9%wydDC3Z@cyDvE%5bz9SP7gy"
 
@@ -24,9 +33,22 @@ func TestMain(m *testing.M) {
 		passport.SetIssuer("beta"),
 		passport.SetKey("eK4qpn7yCBLo0u5mlAFFRCRsCmf2NQ76"),
 	)
+	// Redis-backed tests are skipped when DATABASE_REDIS is not set
+	if url := os.Getenv("DATABASE_REDIS"); url != "" {
+		opts, err := redis.ParseURL(url)
+		if err == nil {
+			rdb = redis.NewClient(opts)
+		}
+	}
 	os.Exit(m.Run())
 }
 
+func requireRedis(t *testing.T) {
+	if rdb == nil {
+		t.Skip("DATABASE_REDIS is not set")
+	}
+}
+
 var jti1 = "GIlmuxUX1n5N4wAVVF40i"
 var userId1 = "FTFD1FnWKwueHAY8h-zXg"
 var jti2 = "gxOWtI58ViI2pl3BHxSNs"
@@ -126,6 +148,31 @@ cT+KvIIC8W/e9k0W7Cm72M1P9jU7SLf/vg==
 	assert.Error(t, err)
 }
 
+func TestStructLiteral(t *testing.T) {
+	// Method and Algorithms default to HS256 without New
+	x := &passport.Passport{Issuer: "dev", Key: key1}
+	ts, err := x.Create(passport.NewClaims(userId1, time.Hour))
+	assert.NoError(t, err)
+	claims, err := x.Verify(ts)
+	assert.NoError(t, err)
+	assert.Equal(t, userId1, claims.ActiveId)
+	_, err = x1.Verify(ts)
+	assert.NoError(t, err)
+
+	hs384 := passport.New(passport.SetIssuer("dev"), passport.SetKey(key1), passport.SetMethod(jwt.SigningMethodHS384))
+	ts, err = hs384.Create(passport.NewClaims(userId1, time.Hour))
+	assert.NoError(t, err)
+	_, err = x.Verify(ts)
+	assert.ErrorIs(t, err, passport.ErrInvalidSigningMethod)
+
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	x = &passport.Passport{Issuer: "dev", PrivateKey: edKey, PublicKeys: []crypto.PublicKey{edPub}}
+	ts, err = x.Create(passport.NewClaims(userId1, time.Hour))
+	assert.NoError(t, err)
+	_, err = x.Verify(ts)
+	assert.NoError(t, err)
+}
+
 func TestSetData(t *testing.T) {
 	claims := passport.NewClaims(userId1, time.Hour*2).SetJTI(jti1).SetData(map[string]interface{}{
 		"role":   "admin",
@@ -150,3 +197,112 @@ func TestNewClaims(t *testing.T) {
 	assert.True(t, claims.ExpiresAt.Time.After(time.Now().Add(59*time.Minute)))
 	assert.True(t, claims.ExpiresAt.Time.Before(time.Now().Add(61*time.Minute)))
 }
+
+func TestAsymmetric(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		key    crypto.Signer
+		method jwt.SigningMethod
+	}{
+		{rsaKey, jwt.SigningMethodRS256},
+		{ecKey, jwt.SigningMethodES256},
+		{edKey, jwt.SigningMethodEdDSA},
+	}
+	for _, v := range cases {
+		issuer := passport.New(
+			passport.SetIssuer("dev"),
+			passport.SetPrivateKey(v.key),
+		)
+		assert.Equal(t, v.method, issuer.Method)
+		assert.Equal(t, []string{v.method.Alg()}, issuer.Algorithms)
+
+		ts, err := issuer.Create(passport.NewClaims(userId1, time.Hour).SetJTI(jti1))
+		assert.NoError(t, err)
+
+		// Verifier only holds the public key
+		verifier := passport.New(
+			passport.SetIssuer("dev"),
+			passport.SetPublicKeys(v.key.Public()),
+		)
+		claims, err := verifier.Verify(ts)
+		assert.NoError(t, err)
+		assert.Equal(t, userId1, claims.ActiveId)
+
+		// Verifier cannot sign
+		_, err = verifier.Create(passport.NewClaims(userId1, time.Hour))
+		assert.ErrorIs(t, err, passport.ErrMissingSigningKey)
+
+		// HS256 passport does not accept asymmetric tokens
+		_, err = x1.Verify(ts)
+		assert.ErrorIs(t, err, passport.ErrInvalidSigningMethod)
+	}
+}
+
+func TestAsymmetric_MultiplePublicKeys(t *testing.T) {
+	k1, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	k2, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	issuer := passport.New(passport.SetIssuer("dev"), passport.SetPrivateKey(k2))
+	ts, err := issuer.Create(passport.NewClaims(userId1, time.Hour))
+	assert.NoError(t, err)
+
+	verifier := passport.New(
+		passport.SetIssuer("dev"),
+		passport.SetPublicKeys(k1.Public(), k2.Public()),
+	)
+	_, err = verifier.Verify(ts)
+	assert.NoError(t, err)
+
+	other := passport.New(passport.SetIssuer("dev"), passport.SetPublicKeys(k1.Public()))
+	_, err = other.Verify(ts)
+	assert.Error(t, err)
+}
+
+func TestAsymmetric_AlgorithmConfusion(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	verifier := passport.New(
+		passport.SetIssuer("dev"),
+		passport.SetPublicKeys(&rsaKey.PublicKey),
+	)
+	// HS256 token signed with the public key bytes must be rejected
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	assert.NoError(t, err)
+	ts, err := jwt.NewWithClaims(jwt.SigningMethodHS256, passport.NewClaims(userId1, time.Hour)).
+		SignedString(der)
+	assert.NoError(t, err)
+	_, err = verifier.Verify(ts)
+	assert.ErrorIs(t, err, passport.ErrInvalidSigningMethod)
+
+	// Explicit allow-list
+	verifier = passport.New(
+		passport.SetIssuer("dev"),
+		passport.SetPublicKeys(&rsaKey.PublicKey),
+		passport.SetAlgorithms("RS256", "RS384"),
+	)
+	ts, err = jwt.NewWithClaims(jwt.SigningMethodRS384, passport.Claims{
+		ActiveId:         userId1,
+		RegisteredClaims: jwt.RegisteredClaims{Issuer: "dev"},
+	}).SignedString(rsaKey)
+	assert.NoError(t, err)
+	_, err = verifier.Verify(ts)
+	assert.NoError(t, err)
+}
+
+func TestVerify_EmptyKey(t *testing.T) {
+	x := passport.New(passport.SetIssuer("dev"))
+	_, err := x.Create(passport.NewClaims(userId1, time.Hour))
+	assert.ErrorIs(t, err, passport.ErrMissingSigningKey)
+
+	ts, err := jwt.NewWithClaims(jwt.SigningMethodHS256, passport.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Issuer: "dev"},
+	}).SignedString([]byte{})
+	assert.NoError(t, err)
+	_, err = x.Verify(ts)
+	assert.ErrorIs(t, err, passport.ErrMissingSigningKey)
+}
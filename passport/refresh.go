@@ -0,0 +1,276 @@
+package passport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kainonly/go/help"
+	"github.com/redis/go-redis/v9"
+)
+
+// Errors returned by refresher functions.
+var (
+	ErrRefreshTokenInvalid = errors.New("passport: refresh token does not exist or expired")
+	ErrRefreshTokenReused  = errors.New("passport: refresh token reuse detected, token family revoked")
+)
+
+// TokenPair is an access token with the refresh token used to renew it.
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresIn is the access token lifetime in seconds.
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+// Refresher issues access/refresh token pairs with Redis storage.
+//
+// Refresh tokens are opaque random strings. Each login starts a token family;
+// every refresh rotates the refresh token within the family. Presenting an
+// already rotated refresh token revokes the whole family, so a stolen token
+// stops working as soon as either party uses it again.
+//
+// Families are indexed by ActiveId, so RevokeAll ends every family of a user.
+// If the Passport has a Revoker, Refresh also rejects families started before
+// a RevokeBefore cutoff.
+//
+//	refresher := passport.NewRefresher(redisClient, auth)
+//
+//	// Login
+//	pair, err := refresher.Issue(ctx, userId, map[string]interface{}{"role": "admin"})
+//
+//	// Refresh
+//	pair, err = refresher.Refresh(ctx, refreshToken)
+//	if errors.Is(err, passport.ErrRefreshTokenReused) {
+//		// possible token theft, the user has to log in again
+//	}
+//
+//	// Logout
+//	refresher.Revoke(ctx, refreshToken)
+//
+//	// Password change - log out everywhere
+//	refresher.RevokeAll(ctx, userId)
+type Refresher struct {
+	// RDb is the Redis client for storing refresh tokens.
+	RDb *redis.Client
+	// Prefix is the key prefix for all refresher keys (default: "passport:refresh").
+	Prefix string
+	// Passport signs the access tokens.
+	Passport *Passport
+	// AccessTTL is the access token lifetime (default: 15 minutes).
+	AccessTTL time.Duration
+	// RefreshTTL is the refresh token lifetime (default: 7 days).
+	RefreshTTL time.Duration
+}
+
+// NewRefresher creates a new Refresher with the given Redis client and Passport.
+func NewRefresher(rdb *redis.Client, passport *Passport, options ...RefresherOption) *Refresher {
+	x := &Refresher{
+		RDb:        rdb,
+		Prefix:     "passport:refresh",
+		Passport:   passport,
+		AccessTTL:  15 * time.Minute,
+		RefreshTTL: 7 * 24 * time.Hour,
+	}
+	for _, opt := range options {
+		opt(x)
+	}
+	return x
+}
+
+// RefresherOption is a function that configures a Refresher instance.
+type RefresherOption func(x *Refresher)
+
+// SetRefreshPrefix sets the Redis key prefix for refresher keys.
+func SetRefreshPrefix(v string) RefresherOption {
+	return func(x *Refresher) {
+		x.Prefix = v
+	}
+}
+
+// SetAccessTTL sets the access token lifetime.
+func SetAccessTTL(v time.Duration) RefresherOption {
+	return func(x *Refresher) {
+		x.AccessTTL = v
+	}
+}
+
+// SetRefreshTTL sets the refresh token lifetime.
+// Each rotation extends the token family by this duration.
+func SetRefreshTTL(v time.Duration) RefresherOption {
+	return func(x *Refresher) {
+		x.RefreshTTL = v
+	}
+}
+
+// Key generates the full Redis key for a refresher name.
+// Format: "{prefix}:{name}"
+func (x *Refresher) Key(name string) string {
+	return fmt.Sprintf("%s:%s", x.Prefix, name)
+}
+
+// tokenKey stores refresh tokens hashed, so a Redis dump does not leak usable tokens.
+func (x *Refresher) tokenKey(token string) string {
+	return x.Key("token:" + help.Sha256hex(token))
+}
+
+func (x *Refresher) familyKey(family string) string {
+	return x.Key("family:" + family)
+}
+
+// activeKey is the set of token families of an ActiveId.
+func (x *Refresher) activeKey(activeId string) string {
+	return x.Key("active:" + activeId)
+}
+
+// Issue starts a new token family and returns the first token pair.
+// Call this on login.
+func (x *Refresher) Issue(ctx context.Context, activeId string, data map[string]interface{}) (*TokenPair, error) {
+	family := help.Random(16)
+	if _, err := x.RDb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, x.familyKey(family), activeId, x.RefreshTTL)
+		p.SAdd(ctx, x.activeKey(activeId), family)
+		p.Expire(ctx, x.activeKey(activeId), x.RefreshTTL)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return x.issue(ctx, family, time.Now().Unix(), activeId, data)
+}
+
+// issue creates a token pair in the family; created is the family start in Unix seconds.
+func (x *Refresher) issue(ctx context.Context, family string, created int64, activeId string, data map[string]interface{}) (*TokenPair, error) {
+	accessToken, err := x.Passport.Create(NewClaims(activeId, x.AccessTTL).
+		SetJTI(help.Uuid7()).
+		SetData(data))
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken := help.Random(32)
+	key := x.tokenKey(refreshToken)
+	if _, err = x.RDb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, key, "family", family, "active_id", activeId, "data", string(b), "used", "0", "created", created)
+		p.Expire(ctx, key, x.RefreshTTL)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(x.AccessTTL.Seconds()),
+	}, nil
+}
+
+// rotate is a Lua script that atomically marks a refresh token as used.
+// Returns {0} if the token does not exist, {-1, family} if it was already
+// used, or {1, family, active_id, data, created} on success.
+var rotate = redis.NewScript(`
+local r = redis.call('HMGET', KEYS[1], 'family', 'used', 'active_id', 'data', 'created')
+if not r[1] then
+    return {0}
+end
+if r[2] == '1' then
+    return {-1, r[1]}
+end
+redis.call('HSET', KEYS[1], 'used', '1')
+return {1, r[1], r[3], r[4], r[5]}
+`)
+
+// Refresh exchanges a refresh token for a new token pair.
+// The presented refresh token is invalidated (rotation).
+// Returns ErrRefreshTokenInvalid if the token does not exist, expired or its family was revoked,
+// including by a Revoker cutoff of the Passport.
+// Returns ErrRefreshTokenReused if the token was already used; the whole family is revoked.
+func (x *Refresher) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	result, err := rotate.Run(ctx, x.RDb, []string{x.tokenKey(refreshToken)}).Slice()
+	if err != nil {
+		return nil, err
+	}
+	switch result[0].(int64) {
+	case 0:
+		return nil, ErrRefreshTokenInvalid
+	case -1:
+		if err = x.RDb.Del(ctx, x.familyKey(result[1].(string))).Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	family, activeId := result[1].(string), result[2].(string)
+	created, err := strconv.ParseInt(result[4].(string), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if x.Passport.Revoker != nil {
+		err = x.Passport.Revoker.check(ctx, "", activeId, jwt.NewNumericDate(time.Unix(created, 0)))
+		if errors.Is(err, ErrTokenRevoked) {
+			if err = x.RDb.Del(ctx, x.familyKey(family)).Err(); err != nil {
+				return nil, err
+			}
+			return nil, ErrRefreshTokenInvalid
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Refresh the family TTL; a revoked family no longer exists
+	ok, err := x.RDb.Expire(ctx, x.familyKey(family), x.RefreshTTL).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrRefreshTokenInvalid
+	}
+	if err = x.RDb.Expire(ctx, x.activeKey(activeId), x.RefreshTTL).Err(); err != nil {
+		return nil, err
+	}
+	var data map[string]interface{}
+	if err = json.Unmarshal([]byte(result[3].(string)), &data); err != nil {
+		return nil, err
+	}
+	return x.issue(ctx, family, created, activeId, data)
+}
+
+// Revoke revokes the token family of the given refresh token.
+// All refresh tokens of the family stop working. Call this on logout.
+func (x *Refresher) Revoke(ctx context.Context, refreshToken string) error {
+	key := x.tokenKey(refreshToken)
+	r, err := x.RDb.HMGet(ctx, key, "family", "active_id").Result()
+	if err != nil {
+		return err
+	}
+	family, ok := r[0].(string)
+	if !ok {
+		return ErrRefreshTokenInvalid
+	}
+	_, err = x.RDb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, x.familyKey(family), key)
+		if activeId, ok := r[1].(string); ok {
+			p.SRem(ctx, x.activeKey(activeId), family)
+		}
+		return nil
+	})
+	return err
+}
+
+// RevokeAll revokes every token family of the given ActiveId,
+// e.g. after a password change or "log out everywhere".
+func (x *Refresher) RevokeAll(ctx context.Context, activeId string) error {
+	families, err := x.RDb.SMembers(ctx, x.activeKey(activeId)).Result()
+	if err != nil {
+		return err
+	}
+	keys := []string{x.activeKey(activeId)}
+	for _, family := range families {
+		keys = append(keys, x.familyKey(family))
+	}
+	return x.RDb.Del(ctx, keys...).Err()
+}
@@ -0,0 +1,122 @@
+package passport_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kainonly/go/passport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefresher(t *testing.T) {
+	requireRedis(t)
+	ctx := context.TODO()
+	r := passport.NewRefresher(rdb, x1, passport.SetAccessTTL(time.Minute))
+	assert.Equal(t, "passport:refresh:test", r.Key("test"))
+
+	pair, err := r.Issue(ctx, userId1, map[string]interface{}{"role": "admin"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, pair.RefreshToken)
+	assert.Equal(t, int64(60), pair.ExpiresIn)
+	claims, err := x1.Verify(pair.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, userId1, claims.ActiveId)
+	assert.NotEmpty(t, claims.ID)
+
+	// Rotation
+	next, err := r.Refresh(ctx, pair.RefreshToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, pair.RefreshToken, next.RefreshToken)
+	claims, err = x1.Verify(next.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, userId1, claims.ActiveId)
+	assert.Equal(t, "admin", claims.Data["role"])
+
+	// Logout
+	assert.NoError(t, r.Revoke(ctx, next.RefreshToken))
+	_, err = r.Refresh(ctx, next.RefreshToken)
+	assert.ErrorIs(t, err, passport.ErrRefreshTokenInvalid)
+	assert.ErrorIs(t, r.Revoke(ctx, "unknown"), passport.ErrRefreshTokenInvalid)
+}
+
+func TestRefresher_ReuseDetection(t *testing.T) {
+	requireRedis(t)
+	ctx := context.TODO()
+	r := passport.NewRefresher(rdb, x1)
+
+	pair, err := r.Issue(ctx, userId2, nil)
+	assert.NoError(t, err)
+	next, err := r.Refresh(ctx, pair.RefreshToken)
+	assert.NoError(t, err)
+
+	// Replaying the rotated token revokes the family
+	_, err = r.Refresh(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, passport.ErrRefreshTokenReused)
+
+	// The legitimate latest token no longer works either
+	_, err = r.Refresh(ctx, next.RefreshToken)
+	assert.ErrorIs(t, err, passport.ErrRefreshTokenInvalid)
+}
+
+func TestRefresher_Invalid(t *testing.T) {
+	requireRedis(t)
+	r := passport.NewRefresher(rdb, x1, passport.SetRefreshPrefix("rt"))
+	assert.Equal(t, "rt:a", r.Key("a"))
+	_, err := r.Refresh(context.TODO(), "does-not-exist")
+	assert.ErrorIs(t, err, passport.ErrRefreshTokenInvalid)
+}
+
+func TestRefresher_RevokeAll(t *testing.T) {
+	requireRedis(t)
+	ctx := context.TODO()
+	r := passport.NewRefresher(rdb, x1)
+
+	a, err := r.Issue(ctx, userId1, nil)
+	assert.NoError(t, err)
+	b, err := r.Issue(ctx, userId1, nil)
+	assert.NoError(t, err)
+	other, err := r.Issue(ctx, userId2, nil)
+	assert.NoError(t, err)
+	b, err = r.Refresh(ctx, b.RefreshToken)
+	assert.NoError(t, err)
+
+	assert.NoError(t, r.RevokeAll(ctx, userId1))
+	_, err = r.Refresh(ctx, a.RefreshToken)
+	assert.ErrorIs(t, err, passport.ErrRefreshTokenInvalid)
+	_, err = r.Refresh(ctx, b.RefreshToken)
+	assert.ErrorIs(t, err, passport.ErrRefreshTokenInvalid)
+	_, err = r.Refresh(ctx, other.RefreshToken)
+	assert.NoError(t, err)
+	assert.NoError(t, r.RevokeAll(ctx, userId2))
+}
+
+func TestRefresher_RevokeBefore(t *testing.T) {
+	requireRedis(t)
+	ctx := context.TODO()
+	revoker := passport.NewRevoker(rdb, passport.SetRevokePrefix("revoked-test"))
+	x := passport.New(
+		passport.SetIssuer("dev"),
+		passport.SetKey(key1),
+		passport.SetRevoker(revoker),
+	)
+	r := passport.NewRefresher(rdb, x)
+
+	pair, err := r.Issue(ctx, userId2, nil)
+	assert.NoError(t, err)
+	// Families started before the cutoff cannot mint access tokens
+	assert.NoError(t, revoker.RevokeBefore(ctx, userId2, time.Now().Add(time.Second)))
+	_, err = r.Refresh(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, passport.ErrRefreshTokenInvalid)
+
+	// A login after the cutoff works
+	time.Sleep(time.Second)
+	pair, err = r.Issue(ctx, userId2, nil)
+	assert.NoError(t, err)
+	next, err := r.Refresh(ctx, pair.RefreshToken)
+	assert.NoError(t, err)
+	_, err = x.VerifyContext(ctx, next.AccessToken)
+	assert.NoError(t, err)
+
+	rdb.Del(ctx, revoker.Key("active:"+userId2))
+}
@@ -0,0 +1,148 @@
+package passport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// Errors returned by revoker functions.
+var (
+	ErrTokenRevoked = errors.New("passport: token has been revoked")
+	ErrMissingJTI   = errors.New("passport: token has no jti")
+)
+
+// Revoker is a token denylist with Redis storage.
+//
+// Single tokens are revoked by JTI; the entry expires together with the token.
+// All tokens of an ActiveId can be revoked at once by a cutoff time, e.g. after
+// a password change or "log out everywhere".
+//
+//	revoker := passport.NewRevoker(redisClient)
+//	auth := passport.New(
+//		passport.SetKey("your-secret-key-at-least-32-bytes"),
+//		passport.SetIssuer("your-app-name"),
+//		passport.SetRevoker(revoker),
+//	)
+//
+//	// Logout - revoke the current token
+//	revoker.Revoke(ctx, claims)
+//
+//	// Password change - revoke every token issued so far
+//	revoker.RevokeBefore(ctx, userId, time.Now())
+//
+//	// VerifyContext consults the denylist
+//	claims, err := auth.VerifyContext(ctx, token)
+type Revoker struct {
+	// RDb is the Redis client for storing revocations.
+	RDb *redis.Client
+	// Prefix is the key prefix for all revoker keys (default: "passport:revoked").
+	Prefix string
+	// MaxTokenAge is how long RevokeBefore cutoffs are kept (default: 24 hours).
+	// It must be at least the longest token lifetime.
+	MaxTokenAge time.Duration
+}
+
+// NewRevoker creates a new Revoker with the given Redis client.
+func NewRevoker(rdb *redis.Client, options ...RevokerOption) *Revoker {
+	x := &Revoker{
+		RDb:         rdb,
+		Prefix:      "passport:revoked",
+		MaxTokenAge: 24 * time.Hour,
+	}
+	for _, opt := range options {
+		opt(x)
+	}
+	return x
+}
+
+// RevokerOption is a function that configures a Revoker instance.
+type RevokerOption func(x *Revoker)
+
+// SetRevokePrefix sets the Redis key prefix for revoker keys.
+func SetRevokePrefix(v string) RevokerOption {
+	return func(x *Revoker) {
+		x.Prefix = v
+	}
+}
+
+// SetMaxTokenAge sets how long RevokeBefore cutoffs are kept.
+func SetMaxTokenAge(v time.Duration) RevokerOption {
+	return func(x *Revoker) {
+		x.MaxTokenAge = v
+	}
+}
+
+// Key generates the full Redis key for a revoker name.
+// Format: "{prefix}:{name}"
+func (x *Revoker) Key(name string) string {
+	return fmt.Sprintf("%s:%s", x.Prefix, name)
+}
+
+// Revoke adds the token to the denylist by its JTI.
+// The entry expires when the token does; already expired tokens are ignored.
+// Returns ErrMissingJTI if the claims have no JTI.
+func (x *Revoker) Revoke(ctx context.Context, claims Claims) error {
+	if claims.ID == "" {
+		return ErrMissingJTI
+	}
+	ttl := x.MaxTokenAge
+	if claims.ExpiresAt != nil {
+		ttl = time.Until(claims.ExpiresAt.Time)
+		if ttl <= 0 {
+			return nil
+		}
+	}
+	return x.RDb.Set(ctx, x.Key("jti:"+claims.ID), 1, ttl).Err()
+}
+
+// setMax is a Lua script that only raises the stored cutoff.
+var setMax = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+if tonumber(ARGV[1]) > current then
+    redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+else
+    redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// RevokeBefore revokes all tokens of the given ActiveId issued before t.
+// Tokens are compared by their iat claim with second precision; tokens issued
+// in the same second as t stay valid, so a token created right after the call is accepted.
+func (x *Revoker) RevokeBefore(ctx context.Context, activeId string, t time.Time) error {
+	return setMax.Run(ctx, x.RDb, []string{x.Key("active:" + activeId)},
+		t.Unix(), x.MaxTokenAge.Milliseconds()).Err()
+}
+
+// Check returns ErrTokenRevoked if the token was revoked by JTI or by RevokeBefore.
+func (x *Revoker) Check(ctx context.Context, claims Claims) error {
+	return x.check(ctx, claims.ID, claims.ActiveId, claims.IssuedAt)
+}
+
+func (x *Revoker) check(ctx context.Context, jti string, activeId string, iat *jwt.NumericDate) error {
+	keys := []string{x.Key("jti:" + jti), x.Key("active:" + activeId)}
+	values, err := x.RDb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return err
+	}
+	if jti != "" && values[0] != nil {
+		return ErrTokenRevoked
+	}
+	if activeId != "" && values[1] != nil {
+		cutoff, err := strconv.ParseInt(values[1].(string), 10, 64)
+		if err != nil {
+			return err
+		}
+		// Tokens without iat cannot prove they are newer than the cutoff
+		if iat == nil || iat.Unix() < cutoff {
+			return ErrTokenRevoked
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package passport_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kainonly/go/passport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevoker(t *testing.T) {
+	requireRedis(t)
+	ctx := context.TODO()
+	r := passport.NewRevoker(rdb)
+	assert.Equal(t, "passport:revoked:a", r.Key("a"))
+	x := passport.New(
+		passport.SetIssuer("dev"),
+		passport.SetKey(key1),
+		passport.SetRevoker(r),
+	)
+
+	ts, err := x.Create(passport.NewClaims(userId1, time.Hour).SetJTI("revoke-jti-1"))
+	assert.NoError(t, err)
+	claims, err := x.VerifyContext(ctx, ts)
+	assert.NoError(t, err)
+
+	assert.NoError(t, r.Revoke(ctx, claims))
+	_, err = x.VerifyContext(ctx, ts)
+	assert.ErrorIs(t, err, passport.ErrTokenRevoked)
+	// Verify does not consult the denylist
+	_, err = x.Verify(ts)
+	assert.NoError(t, err)
+
+	// Entry expires with the token
+	ttl := rdb.TTL(ctx, r.Key("jti:revoke-jti-1")).Val()
+	assert.True(t, ttl > 59*time.Minute && ttl <= time.Hour)
+
+	assert.ErrorIs(t, r.Revoke(ctx, passport.Claims{}), passport.ErrMissingJTI)
+
+	rdb.Del(ctx, r.Key("jti:revoke-jti-1"))
+}
+
+func TestRevoker_RevokeBefore(t *testing.T) {
+	requireRedis(t)
+	ctx := context.TODO()
+	r := passport.NewRevoker(rdb, passport.SetRevokePrefix("revoked-test"), passport.SetMaxTokenAge(time.Hour))
+	x := passport.New(
+		passport.SetIssuer("dev"),
+		passport.SetKey(key1),
+		passport.SetRevoker(r),
+	)
+	old := passport.NewClaims(userId2, time.Hour).SetJTI("old")
+	old.IssuedAt.Time = time.Now().Add(-time.Minute)
+	tsOld, err := x.Create(old)
+	assert.NoError(t, err)
+	recent := passport.NewClaims(userId2, time.Hour).SetJTI("recent")
+	tsRecent, err := x.Create(recent)
+	assert.NoError(t, err)
+
+	assert.NoError(t, r.RevokeBefore(ctx, userId2, time.Now().Add(-30*time.Second)))
+	_, err = x.VerifyContext(ctx, tsOld)
+	assert.ErrorIs(t, err, passport.ErrTokenRevoked)
+	_, err = x.VerifyContext(ctx, tsRecent)
+	assert.NoError(t, err)
+
+	// An earlier cutoff does not lower the stored one
+	assert.NoError(t, r.RevokeBefore(ctx, userId2, time.Now().Add(-time.Hour)))
+	_, err = x.VerifyContext(ctx, tsOld)
+	assert.ErrorIs(t, err, passport.ErrTokenRevoked)
+
+	// Other users are unaffected
+	ts, err := x.Create(passport.NewClaims(userId1, time.Hour))
+	assert.NoError(t, err)
+	_, err = x.VerifyContext(ctx, ts)
+	assert.NoError(t, err)
+
+	rdb.Del(ctx, r.Key("active:"+userId2))
+}
+
+func TestRevoker_RevokeBeforeThenCreate(t *testing.T) {
+	requireRedis(t)
+	ctx := context.TODO()
+	r := passport.NewRevoker(rdb, passport.SetRevokePrefix("revoked-test"), passport.SetMaxTokenAge(time.Hour))
+	x := passport.New(
+		passport.SetIssuer("dev"),
+		passport.SetKey(key1),
+		passport.SetRevoker(r),
+	)
+	old := passport.NewClaims(userId2, time.Hour)
+	old.IssuedAt.Time = time.Now().Add(-time.Second)
+	tsOld, err := x.Create(old)
+	assert.NoError(t, err)
+
+	// Password change followed by a new login
+	assert.NoError(t, r.RevokeBefore(ctx, userId2, time.Now()))
+	ts, err := x.Create(passport.NewClaims(userId2, time.Hour))
+	assert.NoError(t, err)
+	_, err = x.VerifyContext(ctx, ts)
+	assert.NoError(t, err)
+	_, err = x.VerifyContext(ctx, tsOld)
+	assert.ErrorIs(t, err, passport.ErrTokenRevoked)
+
+	rdb.Del(ctx, r.Key("active:"+userId2))
+}
+
+func TestVerifyContext_WithoutRevoker(t *testing.T) {
+	ts, err := x1.Create(passport.NewClaims(userId1, time.Hour))
+	assert.NoError(t, err)
+	claims, err := x1.VerifyContext(context.TODO(), ts)
+	assert.NoError(t, err)
+	assert.Equal(t, userId1, claims.ActiveId)
+}
@@ -0,0 +1,132 @@
+package passport
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kainonly/go/help"
+)
+
+// SigningMethodSM2 signs tokens with SM2 and the SM3 digest (GM/T 0003, GM/T 0004),
+// using the default user ID help.SM2UID. The "alg" header is "SM2".
+//
+// Keys are loaded with the help package:
+//
+//	priv, _ := help.PrivKeySM2FromBase64(privKeyBase64)
+//	auth := passport.New(
+//		passport.SetIssuer("your-app-name"),
+//		passport.SetPrivateKey(priv), // SigningMethodSM2 is inferred from the key
+//	)
+//
+//	pub, _ := help.PubKeySM2FromBase64(pubKeyBase64)
+//	verifier := passport.New(
+//		passport.SetIssuer("your-app-name"),
+//		passport.SetPublicKeys(pub),
+//	)
+//
+// Like ES256, the signature is the 64-byte concatenation of r and s.
+var SigningMethodSM2 = &signingMethodSM2{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodSM2.Alg(), func() jwt.SigningMethod {
+		return SigningMethodSM2
+	})
+}
+
+// ErrInvalidSM2Key is returned when the key is not an SM2 key.
+var ErrInvalidSM2Key = errors.New("passport: key is not a valid SM2 key")
+
+type signingMethodSM2 struct{}
+
+// sm2Size is the byte length of r and s.
+const sm2Size = 32
+
+func (m *signingMethodSM2) Alg() string {
+	return "SM2"
+}
+
+// Sign signs the signing string with an *sm2.PrivateKey.
+func (m *signingMethodSM2) Sign(signingString string, key interface{}) ([]byte, error) {
+	priv, ok := key.(*sm2.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidSM2Key
+	}
+	der, err := priv.Sign(rand.Reader, []byte(signingString), sm2.DefaultSM2SignerOpts)
+	if err != nil {
+		return nil, err
+	}
+	var sig struct{ R, S *big.Int }
+	if _, err = asn1.Unmarshal(der, &sig); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 2*sm2Size)
+	sig.R.FillBytes(out[:sm2Size])
+	sig.S.FillBytes(out[sm2Size:])
+	return out, nil
+}
+
+// Verify verifies the signature with an SM2 *ecdsa.PublicKey (or *sm2.PrivateKey).
+func (m *signingMethodSM2) Verify(signingString string, sig []byte, key interface{}) error {
+	var pub *ecdsa.PublicKey
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		pub = k
+	case *sm2.PrivateKey:
+		pub = &k.PublicKey
+	}
+	if pub == nil || !sm2.IsSM2PublicKey(pub) {
+		return ErrInvalidSM2Key
+	}
+	if len(sig) != 2*sm2Size {
+		return jwt.ErrSignatureInvalid
+	}
+	der, err := asn1.Marshal(struct{ R, S *big.Int }{
+		new(big.Int).SetBytes(sig[:sm2Size]),
+		new(big.Int).SetBytes(sig[sm2Size:]),
+	})
+	if err != nil {
+		return err
+	}
+	if !sm2.VerifyASN1WithSM2(pub, help.SM2UID, []byte(signingString), der) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+// sm2JWK encodes an SM2 public key as a JWK with crv "SM2".
+func sm2JWK(x *JWK, key *ecdsa.PublicKey) {
+	px, py := make([]byte, sm2Size), make([]byte, sm2Size)
+	key.X.FillBytes(px)
+	key.Y.FillBytes(py)
+	x.Kty, x.Crv = "EC", "SM2"
+	x.X, x.Y = b64.EncodeToString(px), b64.EncodeToString(py)
+}
+
+// sm2PublicKey decodes a JWK with crv "SM2".
+func (x JWK) sm2PublicKey() (crypto.PublicKey, error) {
+	px, err := b64.DecodeString(x.X)
+	if err != nil {
+		return nil, err
+	}
+	py, err := b64.DecodeString(x.Y)
+	if err != nil {
+		return nil, err
+	}
+	key, err := sm2.NewPublicKey(append(append([]byte{4}, px...), py...))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJWK, err)
+	}
+	return key, nil
+}
+
+// isSM2 reports whether the public key is on the SM2 curve.
+func isSM2(key crypto.PublicKey) bool {
+	return sm2.IsSM2PublicKey(key)
+}
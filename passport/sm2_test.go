@@ -0,0 +1,92 @@
+package passport_test
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/smx509"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kainonly/go/help"
+	"github.com/kainonly/go/passport"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSM2Keys(t *testing.T) (string, string) {
+	key, err := sm2.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	priv, err := smx509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	pub, err := smx509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(priv), base64.StdEncoding.EncodeToString(pub)
+}
+
+func TestSM2(t *testing.T) {
+	privBase64, pubBase64 := newSM2Keys(t)
+	priv, err := help.PrivKeySM2FromBase64(privBase64)
+	assert.NoError(t, err)
+	pub, err := help.PubKeySM2FromBase64(pubBase64)
+	assert.NoError(t, err)
+
+	issuer := passport.New(passport.SetIssuer("gov"), passport.SetPrivateKey(priv))
+	assert.Equal(t, passport.SigningMethodSM2, issuer.Method)
+	assert.Equal(t, []string{"SM2"}, issuer.Algorithms)
+
+	ts, err := issuer.Create(passport.NewClaims(userId1, time.Hour).SetJTI(jti1))
+	assert.NoError(t, err)
+	token, _, err := jwt.NewParser().ParseUnverified(ts, jwt.MapClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "SM2", token.Header["alg"])
+	assert.NotEmpty(t, token.Header["kid"])
+
+	verifier := passport.New(passport.SetIssuer("gov"), passport.SetPublicKeys(pub))
+	claims, err := verifier.Verify(ts)
+	assert.NoError(t, err)
+	assert.Equal(t, userId1, claims.ActiveId)
+	assert.Equal(t, jti1, claims.ID)
+
+	// Tampered signature
+	parts := strings.Split(ts, ".")
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	sig[0] ^= 0xff
+	parts[2] = base64.RawURLEncoding.EncodeToString(sig)
+	_, err = verifier.Verify(strings.Join(parts, "."))
+	assert.ErrorIs(t, err, jwt.ErrSignatureInvalid)
+
+	// Another SM2 key
+	_, otherPub := newSM2Keys(t)
+	other, _ := help.PubKeySM2FromBase64(otherPub)
+	_, err = passport.New(passport.SetIssuer("gov"), passport.SetPublicKeys(other)).Verify(ts)
+	assert.Error(t, err)
+
+	// HS256 passports reject SM2 tokens
+	_, err = x1.Verify(ts)
+	assert.ErrorIs(t, err, passport.ErrInvalidSigningMethod)
+}
+
+func TestSM2_JWK(t *testing.T) {
+	privBase64, _ := newSM2Keys(t)
+	priv, err := help.PrivKeySM2FromBase64(privBase64)
+	assert.NoError(t, err)
+
+	jwk, err := passport.NewJWK("gm", "SM2", priv.Public())
+	assert.NoError(t, err)
+	assert.Equal(t, "EC", jwk.Kty)
+	assert.Equal(t, "SM2", jwk.Crv)
+
+	pub, err := jwk.PublicKey()
+	assert.NoError(t, err)
+	assert.True(t, priv.PublicKey.Equal(pub))
+
+	// Keyring and JWKS work with SM2 keys
+	ring := passport.NewKeyring(passport.NewPrivateKey("gm", priv))
+	auth := passport.New(passport.SetIssuer("gov"), passport.SetKeySet(ring))
+	ts, err := auth.Create(passport.NewClaims(userId1, time.Hour))
+	assert.NoError(t, err)
+	_, err = auth.Verify(ts)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,114 @@
+package passport
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TypedClaims is Claims with a user-defined payload type instead of a map.
+// It uses the same JSON layout as Claims, so tokens created with either
+// type can be verified with the other.
+//
+//	type Session struct {
+//		Role     string `json:"role"`
+//		TenantId int64  `json:"tenant_id"`
+//	}
+//
+//	token, err := passport.CreateTyped(auth, passport.NewTypedClaims(userId, time.Hour, Session{
+//		Role:     "admin",
+//		TenantId: 42,
+//	}))
+//
+//	claims, err := passport.VerifyTyped[Session](auth, token)
+//	claims.Data.TenantId // int64, no type assertion needed
+type TypedClaims[T any] struct {
+	// ActiveId is the primary identifier (usually user ID or session ID).
+	ActiveId string `json:"active_id,omitempty"`
+	// Data holds the typed custom payload.
+	Data T `json:"data"`
+	// Confirmation binds the token to a client key (RFC 7800 cnf claim).
+	Confirmation *Confirmation `json:"cnf,omitempty"`
+
+	jwt.RegisteredClaims
+}
+
+// NewTypedClaims creates new TypedClaims with the given activeId, expiration duration and payload.
+// It sets IssuedAt and NotBefore to current time.
+func NewTypedClaims[T any](activeId string, expire time.Duration, data T) *TypedClaims[T] {
+	now := time.Now()
+	return &TypedClaims[T]{
+		ActiveId: activeId,
+		Data:     data,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(expire)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+}
+
+// SetJTI sets the JWT ID (jti claim) for token uniqueness.
+func (x *TypedClaims[T]) SetJTI(v string) *TypedClaims[T] {
+	x.RegisteredClaims.ID = v
+	return x
+}
+
+// SetConfirmation binds the token to the client key with the given JWK thumbprint.
+func (x *TypedClaims[T]) SetConfirmation(jkt string) *TypedClaims[T] {
+	x.Confirmation = &Confirmation{JKT: jkt}
+	return x
+}
+
+func (x *TypedClaims[T]) registered() *jwt.RegisteredClaims {
+	return &x.RegisteredClaims
+}
+
+func (x *TypedClaims[T]) activeId() string {
+	return x.ActiveId
+}
+
+// CreateTyped generates a signed JWT token string from typed claims.
+// It behaves like Passport.Create.
+func CreateTyped[T any](x *Passport, claims *TypedClaims[T]) (string, error) {
+	x.prepare(&claims.RegisteredClaims)
+	return x.sign(claims)
+}
+
+// VerifyTyped parses and validates a JWT token string into typed claims.
+// It behaves like Passport.Verify.
+func VerifyTyped[T any](x *Passport, tokenString string) (TypedClaims[T], error) {
+	var claims TypedClaims[T]
+	err := x.parse(context.Background(), tokenString, &claims)
+	return claims, err
+}
+
+// VerifyTypedContext verifies typed claims like VerifyTyped and additionally
+// checks the Revoker denylist, like Passport.VerifyContext.
+func VerifyTypedContext[T any](ctx context.Context, x *Passport, tokenString string) (TypedClaims[T], error) {
+	var claims TypedClaims[T]
+	if err := x.parse(ctx, tokenString, &claims); err != nil {
+		return claims, err
+	}
+	if err := x.checkRevoked(ctx, &claims); err != nil {
+		return claims, err
+	}
+	return claims, nil
+}
+
+// DataAs decodes Claims.Data into a typed payload.
+// Useful with Authenticator, which stores untyped Claims:
+//
+//	claims, _ := passport.GetClaims(c)
+//	session, err := passport.DataAs[Session](claims)
+func DataAs[T any](claims Claims) (T, error) {
+	var v T
+	b, err := json.Marshal(claims.Data)
+	if err != nil {
+		return v, err
+	}
+	err = json.Unmarshal(b, &v)
+	return v, err
+}
@@ -0,0 +1,58 @@
+package passport_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kainonly/go/passport"
+	"github.com/stretchr/testify/assert"
+)
+
+type session struct {
+	Role     string   `json:"role"`
+	TenantId int64    `json:"tenant_id"`
+	Scopes   []string `json:"scopes"`
+}
+
+func TestTypedClaims(t *testing.T) {
+	data := session{Role: "admin", TenantId: 9007199254740993, Scopes: []string{"read", "write"}}
+	ts, err := passport.CreateTyped(x1, passport.NewTypedClaims(userId1, time.Hour, data).SetJTI(jti1))
+	assert.NoError(t, err)
+
+	claims, err := passport.VerifyTyped[session](x1, ts)
+	assert.NoError(t, err)
+	assert.Equal(t, data, claims.Data)
+	assert.Equal(t, userId1, claims.ActiveId)
+	assert.Equal(t, jti1, claims.ID)
+	assert.Equal(t, x1.Issuer, claims.Issuer)
+
+	claims, err = passport.VerifyTypedContext[session](context.TODO(), x1, ts)
+	assert.NoError(t, err)
+	assert.Equal(t, data, claims.Data)
+
+	// Compatible with the untyped API
+	untyped, err := x1.Verify(ts)
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", untyped.Data["role"])
+	decoded, err := passport.DataAs[session](untyped)
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", decoded.Role)
+	assert.Equal(t, []string{"read", "write"}, decoded.Scopes)
+
+	// Validation applies as usual
+	_, err = passport.VerifyTyped[session](x2, ts)
+	assert.Error(t, err)
+}
+
+func TestTypedClaims_FromUntyped(t *testing.T) {
+	ts, err := x1.Create(passport.NewClaims(userId1, time.Hour).SetData(map[string]interface{}{
+		"role":      "viewer",
+		"tenant_id": 7,
+	}))
+	assert.NoError(t, err)
+	claims, err := passport.VerifyTyped[session](x1, ts)
+	assert.NoError(t, err)
+	assert.Equal(t, "viewer", claims.Data.Role)
+	assert.Equal(t, int64(7), claims.Data.TenantId)
+}
@@ -0,0 +1,117 @@
+package passport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Errors returned by token validation.
+// They wrap the underlying jwt error, so errors.Is works with both.
+var (
+	ErrTokenExpired          = errors.New("passport: token is expired")
+	ErrTokenNotValidYet      = errors.New("passport: token is not valid yet")
+	ErrTokenUsedBeforeIssued = errors.New("passport: token used before issued")
+	ErrInvalidAudience       = errors.New("passport: token audience does not match")
+	ErrMissingClaim          = errors.New("passport: token is missing required claim")
+	ErrTokenTooOld           = errors.New("passport: token exceeds maximum age")
+)
+
+// tokenClaims is implemented by claim types that embed jwt.RegisteredClaims.
+type tokenClaims interface {
+	jwt.Claims
+	registered() *jwt.RegisteredClaims
+	activeId() string
+}
+
+// jwtErrors maps jwt validation errors to passport sentinels.
+var jwtErrors = []struct {
+	jwt error
+	err error
+}{
+	{jwt.ErrTokenExpired, ErrTokenExpired},
+	{jwt.ErrTokenNotValidYet, ErrTokenNotValidYet},
+	{jwt.ErrTokenUsedBeforeIssued, ErrTokenUsedBeforeIssued},
+	{jwt.ErrTokenInvalidAudience, ErrInvalidAudience},
+	// The parser only requires aud, when an audience is configured
+	{jwt.ErrTokenRequiredClaimMissing, ErrInvalidAudience},
+}
+
+// unknownMethod reports whether the parser rejected the token for an unknown
+// or missing alg header, which fails before keyFunc runs.
+func unknownMethod(token *jwt.Token, err error) bool {
+	return token != nil && token.Method == nil && errors.Is(err, jwt.ErrTokenUnverifiable)
+}
+
+// parse verifies the token and validates the claims according to the Passport options.
+func (x *Passport) parse(ctx context.Context, tokenString string, claims tokenClaims) error {
+	if x.Encrypter != nil {
+		if !isJWE(tokenString) {
+			return ErrNotEncrypted
+		}
+		b, err := x.Encrypter.Decrypt(tokenString)
+		if err != nil {
+			return err
+		}
+		tokenString = string(b)
+	}
+	opts := []jwt.ParserOption{jwt.WithLeeway(x.Leeway), jwt.WithIssuedAt()}
+	if len(x.Audience) != 0 {
+		opts = append(opts, jwt.WithAudience(x.Audience...))
+	}
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		return x.keyFunc(ctx, token)
+	}
+	if token, err := jwt.NewParser(opts...).ParseWithClaims(tokenString, claims, keyFunc); err != nil {
+		if unknownMethod(token, err) {
+			return fmt.Errorf("%w: %w", ErrInvalidSigningMethod, err)
+		}
+		for _, v := range jwtErrors {
+			if errors.Is(err, v.jwt) {
+				return fmt.Errorf("%w: %w", v.err, err)
+			}
+		}
+		return err
+	}
+	rc := claims.registered()
+	if rc.Issuer != x.Issuer {
+		return ErrInvalidIssuer
+	}
+	for _, name := range x.RequiredClaims {
+		if !hasClaim(rc, claims.activeId(), name) {
+			return fmt.Errorf("%w: %s", ErrMissingClaim, name)
+		}
+	}
+	if x.MaxAge > 0 {
+		if rc.IssuedAt == nil || time.Since(rc.IssuedAt.Time) > x.MaxAge+x.Leeway {
+			return ErrTokenTooOld
+		}
+	}
+	return nil
+}
+
+// claimNames are the names supported by SetRequiredClaims.
+var claimNames = []string{"jti", "sub", "aud", "exp", "iat", "nbf", "active_id"}
+
+func hasClaim(rc *jwt.RegisteredClaims, activeId string, name string) bool {
+	switch name {
+	case "jti":
+		return rc.ID != ""
+	case "sub":
+		return rc.Subject != ""
+	case "aud":
+		return len(rc.Audience) != 0
+	case "exp":
+		return rc.ExpiresAt != nil
+	case "iat":
+		return rc.IssuedAt != nil
+	case "nbf":
+		return rc.NotBefore != nil
+	case "active_id":
+		return activeId != ""
+	}
+	return false
+}
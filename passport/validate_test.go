@@ -0,0 +1,119 @@
+package passport_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kainonly/go/passport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify_Audience(t *testing.T) {
+	api := passport.New(passport.SetIssuer("dev"), passport.SetKey(key1), passport.SetAudience("api"))
+	admin := passport.New(passport.SetIssuer("dev"), passport.SetKey(key1), passport.SetAudience("admin", "ops"))
+
+	ts, err := api.Create(passport.NewClaims(userId1, time.Hour))
+	assert.NoError(t, err)
+	claims, err := api.Verify(ts)
+	assert.NoError(t, err)
+	assert.Equal(t, jwt.ClaimStrings{"api"}, claims.Audience)
+
+	_, err = admin.Verify(ts)
+	assert.ErrorIs(t, err, passport.ErrInvalidAudience)
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalidAudience)
+
+	// Explicit audience on the claims is kept
+	c := passport.NewClaims(userId1, time.Hour)
+	c.Audience = jwt.ClaimStrings{"ops"}
+	ts, err = api.Create(c)
+	assert.NoError(t, err)
+	_, err = admin.Verify(ts)
+	assert.NoError(t, err)
+}
+
+func TestVerify_RequiredClaims(t *testing.T) {
+	x := passport.New(
+		passport.SetIssuer("dev"),
+		passport.SetKey(key1),
+		passport.SetRequiredClaims("jti", "active_id"),
+	)
+	ts, err := x.Create(passport.NewClaims(userId1, time.Hour))
+	assert.NoError(t, err)
+	_, err = x.Verify(ts)
+	assert.ErrorIs(t, err, passport.ErrMissingClaim)
+	assert.Contains(t, err.Error(), "jti")
+
+	ts, err = x.Create(passport.NewClaims("", time.Hour).SetJTI(jti1))
+	assert.NoError(t, err)
+	_, err = x.Verify(ts)
+	assert.ErrorIs(t, err, passport.ErrMissingClaim)
+	assert.Contains(t, err.Error(), "active_id")
+
+	ts, err = x.Create(passport.NewClaims(userId1, time.Hour).SetJTI(jti1))
+	assert.NoError(t, err)
+	_, err = x.Verify(ts)
+	assert.NoError(t, err)
+
+	// Names are case-sensitive
+	assert.Panics(t, func() {
+		passport.New(passport.SetIssuer("dev"), passport.SetKey(key1), passport.SetRequiredClaims("JTI"))
+	})
+}
+
+func TestVerify_Leeway(t *testing.T) {
+	strict := passport.New(passport.SetIssuer("dev"), passport.SetKey(key1))
+	lenient := passport.New(passport.SetIssuer("dev"), passport.SetKey(key1), passport.SetLeeway(time.Minute))
+
+	expired := passport.NewClaims(userId1, -30*time.Second)
+	ts, err := strict.Create(expired)
+	assert.NoError(t, err)
+	_, err = strict.Verify(ts)
+	assert.ErrorIs(t, err, passport.ErrTokenExpired)
+	assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+	_, err = lenient.Verify(ts)
+	assert.NoError(t, err)
+
+	future := passport.NewClaims(userId1, time.Hour)
+	future.NotBefore = jwt.NewNumericDate(time.Now().Add(30 * time.Second))
+	ts, err = strict.Create(future)
+	assert.NoError(t, err)
+	_, err = strict.Verify(ts)
+	assert.ErrorIs(t, err, passport.ErrTokenNotValidYet)
+	_, err = lenient.Verify(ts)
+	assert.NoError(t, err)
+
+	issuedLater := passport.NewClaims(userId1, time.Hour)
+	issuedLater.IssuedAt = jwt.NewNumericDate(time.Now().Add(30 * time.Second))
+	issuedLater.NotBefore = nil
+	ts, err = strict.Create(issuedLater)
+	assert.NoError(t, err)
+	_, err = strict.Verify(ts)
+	assert.ErrorIs(t, err, passport.ErrTokenUsedBeforeIssued)
+	_, err = lenient.Verify(ts)
+	assert.NoError(t, err)
+}
+
+func TestVerify_MaxAge(t *testing.T) {
+	x := passport.New(passport.SetIssuer("dev"), passport.SetKey(key1), passport.SetMaxAge(time.Hour))
+
+	old := passport.NewClaims(userId1, 24*time.Hour)
+	old.IssuedAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Hour))
+	old.NotBefore = old.IssuedAt
+	ts, err := x.Create(old)
+	assert.NoError(t, err)
+	_, err = x.Verify(ts)
+	assert.ErrorIs(t, err, passport.ErrTokenTooOld)
+
+	noIat := passport.NewClaims(userId1, time.Hour)
+	noIat.IssuedAt = nil
+	ts, err = x.Create(noIat)
+	assert.NoError(t, err)
+	_, err = x.Verify(ts)
+	assert.ErrorIs(t, err, passport.ErrTokenTooOld)
+
+	ts, err = x.Create(passport.NewClaims(userId1, time.Hour))
+	assert.NoError(t, err)
+	_, err = x.Verify(ts)
+	assert.NoError(t, err)
+}
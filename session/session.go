@@ -0,0 +1,415 @@
+// Package session provides opaque server-side sessions with Redis storage.
+//
+// It is an alternative to passport JWTs for applications such as admin consoles,
+// where sessions must be listed and terminated on the server. Tokens are random
+// strings (help.Random) carrying no data; the session payload lives in Redis with
+// sliding expiration.
+//
+// # Hertz Backend Setup
+//
+//	// Initialize sessions with Redis client
+//	sessions := session.New(redisClient,
+//		session.SetTTL(30*time.Minute),        // idle timeout
+//		session.SetMaxLifetime(12*time.Hour),  // absolute timeout
+//	)
+//
+//	// Login endpoint - create session and set cookie
+//	h.POST("/auth/login", func(ctx context.Context, c *app.RequestContext) {
+//		// ... validate credentials ...
+//		token, _, err := sessions.Create(ctx, userId, map[string]interface{}{"role": "admin"}, session.ClientFrom(c))
+//		if err != nil {
+//			c.JSON(500, utils.H{"error": err.Error()})
+//			return
+//		}
+//		sessions.SetCookie(c, token)
+//		c.JSON(200, utils.H{"message": "ok"})
+//	})
+//
+//	// Protected routes - token from cookie (default) or "Authorization: Bearer"
+//	api := h.Group("/api", sessions.Authenticate())
+//	api.GET("/profile", func(ctx context.Context, c *app.RequestContext) {
+//		s, _ := session.GetSession(c)
+//		c.JSON(200, utils.H{"userId": s.ActiveId})
+//	})
+//
+//	// List and terminate sessions of the current user
+//	api.GET("/sessions", func(ctx context.Context, c *app.RequestContext) {
+//		list, _ := sessions.List(ctx, session.GetActiveId(c))
+//		c.JSON(200, list)
+//	})
+//	api.DELETE("/sessions/:id", func(ctx context.Context, c *app.RequestContext) {
+//		sessions.Terminate(ctx, session.GetActiveId(c), c.Param("id"))
+//		c.JSON(200, utils.H{"message": "ok"})
+//	})
+//
+// # Security Notes
+//
+//   - Only the SHA-256 hash of a token is stored, a Redis dump does not leak usable tokens
+//   - Session.ID is the public identifier for listing and termination, never the token
+//   - The session cookie is HttpOnly, Secure and SameSite=Strict; combine with csrf
+//   - Use SetMaxLifetime to force re-authentication of long-running sessions
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/kainonly/go/help"
+	"github.com/kainonly/go/passport"
+	"github.com/redis/go-redis/v9"
+)
+
+// Default configuration values.
+const (
+	DefaultCookieName  = "SESSION"
+	DefaultTokenLength = 32
+)
+
+// ContextKey is the RequestContext key under which Authenticate stores the Session.
+const ContextKey = "session:session"
+
+// Errors returned by session functions.
+var (
+	ErrNotExists    = errors.New("session: session does not exist or expired")
+	ErrMissingToken = errors.New("session: missing token")
+)
+
+// Session is the server-side state of a session.
+type Session struct {
+	// ID is the public session identifier (hash of the token).
+	ID string `json:"id"`
+	// ActiveId is the primary identifier (usually user ID).
+	ActiveId string `json:"active_id"`
+	// Data holds additional custom data.
+	Data map[string]interface{} `json:"data,omitempty"`
+	// CreatedAt is when the session was created.
+	CreatedAt time.Time `json:"created_at"`
+	// LastSeenAt is when the session was last used.
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// IP is the client IP at creation.
+	IP string `json:"ip,omitempty"`
+	// UserAgent is the client user agent at creation.
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// Client describes the client a session is created for.
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// ClientFrom returns the client IP and user agent of the request.
+func ClientFrom(c *app.RequestContext) Client {
+	return Client{
+		IP:        c.ClientIP(),
+		UserAgent: string(c.UserAgent()),
+	}
+}
+
+// Manager manages sessions with Redis storage.
+type Manager struct {
+	// RDb is the Redis client for storing sessions.
+	RDb *redis.Client
+	// Prefix is the key prefix for all session keys (default: "session").
+	Prefix string
+	// TTL is the idle timeout, extended on every use (default: 30 minutes).
+	TTL time.Duration
+	// MaxLifetime is the absolute timeout since creation (default: 0, disabled).
+	MaxLifetime time.Duration
+	// CookieName is the session cookie name (default: "SESSION").
+	CookieName string
+	// Extractors are tried in order by Authenticate (default: cookie, then bearer).
+	Extractors []passport.Extractor
+	// Code is the error code in the Authenticate response body (default: 0).
+	Code int64
+}
+
+// New creates a new Manager with the given Redis client.
+func New(rdb *redis.Client, options ...Option) *Manager {
+	x := &Manager{
+		RDb:        rdb,
+		Prefix:     "session",
+		TTL:        30 * time.Minute,
+		CookieName: DefaultCookieName,
+	}
+	for _, opt := range options {
+		opt(x)
+	}
+	if x.Extractors == nil {
+		x.Extractors = []passport.Extractor{passport.FromCookie(x.CookieName), passport.FromBearer()}
+	}
+	return x
+}
+
+// Option is a function that configures a Manager instance.
+type Option func(x *Manager)
+
+// SetPrefix sets the Redis key prefix for session keys.
+func SetPrefix(v string) Option {
+	return func(x *Manager) {
+		x.Prefix = v
+	}
+}
+
+// SetTTL sets the idle timeout. Each use of the session extends it.
+func SetTTL(v time.Duration) Option {
+	return func(x *Manager) {
+		x.TTL = v
+	}
+}
+
+// SetMaxLifetime sets the absolute timeout since creation.
+func SetMaxLifetime(v time.Duration) Option {
+	return func(x *Manager) {
+		x.MaxLifetime = v
+	}
+}
+
+// SetCookieName sets the session cookie name.
+func SetCookieName(v string) Option {
+	return func(x *Manager) {
+		x.CookieName = v
+	}
+}
+
+// SetExtractors sets where Authenticate looks for the token, in order of precedence.
+func SetExtractors(v ...passport.Extractor) Option {
+	return func(x *Manager) {
+		x.Extractors = v
+	}
+}
+
+// SetErrorCode sets the error code returned in the Authenticate response body.
+func SetErrorCode(v int64) Option {
+	return func(x *Manager) {
+		x.Code = v
+	}
+}
+
+// Key generates the full Redis key for a session name.
+// Format: "{prefix}:{name}"
+func (x *Manager) Key(name string) string {
+	return fmt.Sprintf("%s:%s", x.Prefix, name)
+}
+
+func (x *Manager) userKey(activeId string) string {
+	return x.Key("user:" + activeId)
+}
+
+// Create starts a new session and returns its token.
+// The token is only returned here; Redis stores its hash.
+func (x *Manager) Create(ctx context.Context, activeId string, data map[string]interface{}, client Client) (string, *Session, error) {
+	token := help.Random(DefaultTokenLength)
+	now := time.Now()
+	s := &Session{
+		ID:         help.Sha256hex(token),
+		ActiveId:   activeId,
+		Data:       data,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		IP:         client.IP,
+		UserAgent:  client.UserAgent,
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err = x.RDb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, x.Key(s.ID), b, x.ttl(s))
+		p.SAdd(ctx, x.userKey(activeId), s.ID)
+		p.Expire(ctx, x.userKey(activeId), x.userTTL())
+		return nil
+	}); err != nil {
+		return "", nil, err
+	}
+	return token, s, nil
+}
+
+// Verify returns the session of the given token and extends its expiration.
+// Returns ErrNotExists if the session does not exist, expired or was terminated.
+func (x *Manager) Verify(ctx context.Context, token string) (*Session, error) {
+	s, err := x.get(ctx, help.Sha256hex(token))
+	if err != nil {
+		return nil, err
+	}
+	ttl := x.ttl(s)
+	if ttl <= 0 {
+		x.Terminate(ctx, s.ActiveId, s.ID)
+		return nil, ErrNotExists
+	}
+	s.LastSeenAt = time.Now()
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	// XX: a session terminated concurrently is not recreated nor accepted
+	ok, err := x.RDb.SetXX(ctx, x.Key(s.ID), b, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotExists
+	}
+	x.RDb.Expire(ctx, x.userKey(s.ActiveId), x.userTTL())
+	return s, nil
+}
+
+// ttl returns the sliding expiration, capped by MaxLifetime.
+func (x *Manager) ttl(s *Session) time.Duration {
+	if x.MaxLifetime > 0 {
+		if remaining := time.Until(s.CreatedAt.Add(x.MaxLifetime)); remaining < x.TTL {
+			return remaining
+		}
+	}
+	return x.TTL
+}
+
+// userTTL keeps the per-user index at least as long as any of its sessions.
+func (x *Manager) userTTL() time.Duration {
+	if x.MaxLifetime > x.TTL {
+		return x.MaxLifetime
+	}
+	return x.TTL
+}
+
+func (x *Manager) get(ctx context.Context, id string) (*Session, error) {
+	b, err := x.RDb.Get(ctx, x.Key(id)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrNotExists
+		}
+		return nil, err
+	}
+	var s Session
+	if err = json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// List returns all active sessions of the given ActiveId.
+// Expired sessions are removed from the index.
+func (x *Manager) List(ctx context.Context, activeId string) ([]*Session, error) {
+	ids, err := x.RDb.SMembers(ctx, x.userKey(activeId)).Result()
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]*Session, 0, len(ids))
+	for _, id := range ids {
+		s, err := x.get(ctx, id)
+		if err != nil {
+			if errors.Is(err, ErrNotExists) {
+				x.RDb.SRem(ctx, x.userKey(activeId), id)
+				continue
+			}
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// Delete terminates the session of the given token (logout).
+// Returns the number of sessions deleted (0 or 1).
+func (x *Manager) Delete(ctx context.Context, token string) int64 {
+	id := help.Sha256hex(token)
+	s, err := x.get(ctx, id)
+	if err != nil {
+		return 0
+	}
+	return x.Terminate(ctx, s.ActiveId, id)
+}
+
+// Terminate deletes a session by its public ID.
+// The session must belong to the given ActiveId.
+// Returns the number of sessions deleted (0 or 1).
+func (x *Manager) Terminate(ctx context.Context, activeId string, id string) int64 {
+	if x.RDb.SRem(ctx, x.userKey(activeId), id).Val() == 0 {
+		return 0
+	}
+	return x.RDb.Del(ctx, x.Key(id)).Val()
+}
+
+// TerminateAll deletes all sessions of the given ActiveId.
+// Returns the number of sessions deleted.
+func (x *Manager) TerminateAll(ctx context.Context, activeId string) int64 {
+	ids := x.RDb.SMembers(ctx, x.userKey(activeId)).Val()
+	keys := []string{x.userKey(activeId)}
+	for _, id := range ids {
+		keys = append(keys, x.Key(id))
+	}
+	n := x.RDb.Del(ctx, keys...).Val()
+	if n > 0 {
+		n-- // the index key itself
+	}
+	return n
+}
+
+// SetCookie sets the session cookie on the response.
+// The cookie is HttpOnly, Secure, SameSite=Strict and lives as long as MaxLifetime
+// (or the browser session if MaxLifetime is not set).
+func (x *Manager) SetCookie(c *app.RequestContext, token string) {
+	c.SetCookie(x.CookieName, token, int(x.MaxLifetime.Seconds()), "/", "",
+		protocol.CookieSameSiteStrictMode, true, true)
+}
+
+// ClearCookie removes the session cookie.
+func (x *Manager) ClearCookie(c *app.RequestContext) {
+	c.SetCookie(x.CookieName, "", -1, "/", "", protocol.CookieSameSiteStrictMode, true, true)
+}
+
+// Authenticate returns a Hertz middleware that verifies the session token.
+// On success the Session is stored under ContextKey; on a missing or unknown
+// session the request is aborted with 401 and a help.R body. Other errors
+// (e.g. Redis failures) abort with 500 and are recorded with c.Error for
+// help.ErrorHandler.
+func (x *Manager) Authenticate() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		var token string
+		for _, extract := range x.Extractors {
+			if token = extract(c); token != "" {
+				break
+			}
+		}
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, help.Fail(x.Code, ErrMissingToken.Error()))
+			return
+		}
+		s, err := x.Verify(ctx, token)
+		if err != nil {
+			if errors.Is(err, ErrNotExists) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, help.Fail(x.Code, err.Error()))
+				return
+			}
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Set(ContextKey, s)
+		c.Next(ctx)
+	}
+}
+
+// GetSession returns the Session stored by Authenticate.
+func GetSession(c *app.RequestContext) (*Session, bool) {
+	v, ok := c.Get(ContextKey)
+	if !ok {
+		return nil, false
+	}
+	s, ok := v.(*Session)
+	return s, ok
+}
+
+// GetActiveId returns the ActiveId of the Session stored by Authenticate,
+// or an empty string if the request is not authenticated.
+func GetActiveId(c *app.RequestContext) string {
+	if s, ok := GetSession(c); ok {
+		return s.ActiveId
+	}
+	return ""
+}
@@ -0,0 +1,217 @@
+package session_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/kainonly/go/help"
+	"github.com/kainonly/go/session"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+var x *session.Manager
+
+func TestMain(m *testing.M) {
+	url := os.Getenv("DATABASE_REDIS")
+	if url == "" {
+		os.Exit(0)
+	}
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		os.Exit(0)
+	}
+	x = session.New(redis.NewClient(opts), session.SetPrefix("test:session"))
+	os.Exit(m.Run())
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "test:session:abc", x.Key("abc"))
+
+	x2 := session.New(x.RDb)
+	assert.Equal(t, "session:abc", x2.Key("abc"))
+	assert.Equal(t, 30*time.Minute, x2.TTL)
+	assert.Equal(t, session.DefaultCookieName, x2.CookieName)
+}
+
+func TestCreateAndVerify(t *testing.T) {
+	ctx := context.TODO()
+	client := session.Client{IP: "127.0.0.1", UserAgent: "test"}
+	token, s, err := x.Create(ctx, "user-create", map[string]interface{}{"role": "admin"}, client)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.Equal(t, help.Sha256hex(token), s.ID)
+	defer x.TerminateAll(ctx, "user-create")
+
+	v, err := x.Verify(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, s.ID, v.ID)
+	assert.Equal(t, "user-create", v.ActiveId)
+	assert.Equal(t, "admin", v.Data["role"])
+	assert.Equal(t, "127.0.0.1", v.IP)
+	assert.Equal(t, "test", v.UserAgent)
+	assert.False(t, v.LastSeenAt.Before(s.LastSeenAt))
+
+	// The token itself is not stored
+	assert.Equal(t, int64(0), x.RDb.Exists(ctx, x.Key(token)).Val())
+
+	_, err = x.Verify(ctx, "invalid-token")
+	assert.ErrorIs(t, err, session.ErrNotExists)
+}
+
+func TestSlidingExpiration(t *testing.T) {
+	ctx := context.TODO()
+	x2 := session.New(x.RDb, session.SetPrefix("test:session"), session.SetTTL(time.Minute))
+	token, s, err := x2.Create(ctx, "user-sliding", nil, session.Client{})
+	assert.NoError(t, err)
+	defer x2.TerminateAll(ctx, "user-sliding")
+
+	x2.RDb.Expire(ctx, x2.Key(s.ID), 10*time.Second)
+	_, err = x2.Verify(ctx, token)
+	assert.NoError(t, err)
+	assert.Greater(t, x2.RDb.TTL(ctx, x2.Key(s.ID)).Val(), 50*time.Second)
+}
+
+func TestMaxLifetime(t *testing.T) {
+	ctx := context.TODO()
+	x2 := session.New(x.RDb, session.SetPrefix("test:session"),
+		session.SetTTL(time.Hour), session.SetMaxLifetime(time.Minute))
+	token, s, err := x2.Create(ctx, "user-lifetime", nil, session.Client{})
+	assert.NoError(t, err)
+	defer x2.TerminateAll(ctx, "user-lifetime")
+
+	// The idle timeout is capped by the absolute timeout
+	assert.LessOrEqual(t, x2.RDb.TTL(ctx, x2.Key(s.ID)).Val(), time.Minute)
+
+	_, err = x2.Verify(ctx, token)
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, x2.RDb.TTL(ctx, x2.Key(s.ID)).Val(), time.Minute)
+}
+
+func TestListAndTerminate(t *testing.T) {
+	ctx := context.TODO()
+	token1, s1, err := x.Create(ctx, "user-list", nil, session.Client{UserAgent: "a"})
+	assert.NoError(t, err)
+	_, s2, err := x.Create(ctx, "user-list", nil, session.Client{UserAgent: "b"})
+	assert.NoError(t, err)
+	defer x.TerminateAll(ctx, "user-list")
+
+	list, err := x.List(ctx, "user-list")
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	// Sessions of other users cannot be terminated
+	assert.Equal(t, int64(0), x.Terminate(ctx, "user-other", s1.ID))
+
+	assert.Equal(t, int64(1), x.Terminate(ctx, "user-list", s1.ID))
+	_, err = x.Verify(ctx, token1)
+	assert.ErrorIs(t, err, session.ErrNotExists)
+
+	list, err = x.List(ctx, "user-list")
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, s2.ID, list[0].ID)
+
+	// Expired sessions are pruned from the index
+	x.RDb.Del(ctx, x.Key(s2.ID))
+	list, err = x.List(ctx, "user-list")
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestDelete(t *testing.T) {
+	ctx := context.TODO()
+	token, _, err := x.Create(ctx, "user-delete", nil, session.Client{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(1), x.Delete(ctx, token))
+	assert.Equal(t, int64(0), x.Delete(ctx, token))
+	_, err = x.Verify(ctx, token)
+	assert.ErrorIs(t, err, session.ErrNotExists)
+}
+
+func TestTerminateAll(t *testing.T) {
+	ctx := context.TODO()
+	token1, _, _ := x.Create(ctx, "user-all", nil, session.Client{})
+	token2, _, _ := x.Create(ctx, "user-all", nil, session.Client{})
+
+	assert.Equal(t, int64(2), x.TerminateAll(ctx, "user-all"))
+	_, err := x.Verify(ctx, token1)
+	assert.ErrorIs(t, err, session.ErrNotExists)
+	_, err = x.Verify(ctx, token2)
+	assert.ErrorIs(t, err, session.ErrNotExists)
+	assert.Equal(t, int64(0), x.TerminateAll(ctx, "user-all"))
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.TODO()
+	token, _, err := x.Create(ctx, "user-authn", nil, session.Client{})
+	assert.NoError(t, err)
+	defer x.TerminateAll(ctx, "user-authn")
+
+	r := route.NewEngine(config.NewOptions(nil))
+	r.GET("/profile", x.Authenticate(), func(ctx context.Context, c *app.RequestContext) {
+		s, ok := session.GetSession(c)
+		assert.True(t, ok)
+		c.String(http.StatusOK, s.ActiveId+":"+session.GetActiveId(c))
+	})
+
+	w := ut.PerformRequest(r, "GET", "/profile", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode())
+	assert.Contains(t, string(w.Result().Body()), session.ErrMissingToken.Error())
+
+	w = ut.PerformRequest(r, "GET", "/profile", nil,
+		ut.Header{Key: "Cookie", Value: session.DefaultCookieName + "=" + token})
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode())
+	assert.Equal(t, "user-authn:user-authn", string(w.Result().Body()))
+
+	w = ut.PerformRequest(r, "GET", "/profile", nil,
+		ut.Header{Key: "Authorization", Value: "Bearer " + token})
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode())
+
+	w = ut.PerformRequest(r, "GET", "/profile", nil,
+		ut.Header{Key: "Authorization", Value: "Bearer invalid"})
+	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode())
+	assert.Contains(t, string(w.Result().Body()), session.ErrNotExists.Error())
+}
+
+func TestAuthenticate_ServerError(t *testing.T) {
+	// Nothing listens on this address
+	broken := session.New(redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1}))
+	r := route.NewEngine(config.NewOptions(nil))
+	r.GET("/profile", broken.Authenticate(), func(ctx context.Context, c *app.RequestContext) {
+		t.Fatal("handler must not run")
+	})
+
+	w := ut.PerformRequest(r, "GET", "/profile", nil,
+		ut.Header{Key: "Authorization", Value: "Bearer token"})
+	assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode())
+	assert.NotContains(t, string(w.Result().Body()), "127.0.0.1")
+}
+
+func TestSetCookie(t *testing.T) {
+	r := route.NewEngine(config.NewOptions(nil))
+	r.GET("/login", func(ctx context.Context, c *app.RequestContext) {
+		x.SetCookie(c, "token-value")
+	})
+	r.GET("/logout", func(ctx context.Context, c *app.RequestContext) {
+		x.ClearCookie(c)
+	})
+
+	w := ut.PerformRequest(r, "GET", "/login", nil)
+	cookie := string(w.Result().Header.Peek("Set-Cookie"))
+	assert.Contains(t, cookie, session.DefaultCookieName+"=token-value")
+	assert.Contains(t, cookie, "HttpOnly")
+	assert.Contains(t, cookie, "secure")
+	assert.Contains(t, cookie, "SameSite=Strict")
+
+	w = ut.PerformRequest(r, "GET", "/logout", nil)
+	assert.Contains(t, string(w.Result().Header.Peek("Set-Cookie")), "max-age=0")
+}